@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/marwanhawari/stew/constants"
+	stew "github.com/marwanhawari/stew/lib"
+)
+
+// maxAdoptionSuggestions is the number of search results that are suggested for a binary that is adopted without --from
+const maxAdoptionSuggestions = 10
+
+// Adopt is executed when you run `stew adopt`
+func Adopt(from string, cliInputs []string) {
+	userOS, userArch, _, systemInfo, err := stew.Initialize()
+	stew.CatchAndExit(err)
+
+	if from != "" && len(cliInputs) != 1 {
+		stew.CatchAndExit(stew.AdoptFromMultipleBinariesError{})
+	}
+
+	lockFile, err := stew.NewLockFile(systemInfo.StewLockFilePath, userOS, userArch)
+	stew.CatchAndExit(err)
+
+	binaryPaths := []string{}
+	for _, cliInput := range cliInputs {
+		binaryPath, err := stew.ResolveBinaryPath(cliInput, systemInfo.StewBinPath, os.Getenv("PATH"))
+		stew.CatchAndExit(err)
+		binaryPaths = append(binaryPaths, binaryPath)
+	}
+	// Without any input, every executable in the stewBinPath that stew doesn't manage yet is offered for adoption
+	if len(cliInputs) == 0 {
+		binaryPaths, err = stew.FindAdoptionCandidates(systemInfo.StewBinPath, lockFile)
+		stew.CatchAndExit(err)
+		if len(binaryPaths) == 0 {
+			fmt.Printf("✨ Every binary in %v is already managed by stew\n", constants.GreenColor(systemInfo.StewBinPath))
+			return
+		}
+	}
+
+	for _, binaryPath := range binaryPaths {
+		err := adoptOne(binaryPath, from, userOS, userArch, systemInfo, &lockFile)
+		if len(binaryPaths) == 1 {
+			stew.CatchAndExit(err)
+		} else if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+}
+
+func adoptOne(binaryPath, from, userOS, userArch string, systemInfo stew.SystemInfo, lockFile *stew.LockFile) error {
+	binaryName := filepath.Base(binaryPath)
+	if _, managed := stew.FindBinaryInLockFile(*lockFile, binaryName); managed {
+		return stew.BinaryAlreadyManagedError{Binary: binaryName}
+	}
+	fmt.Println(constants.GreenColor(binaryName))
+
+	if from == "" {
+		var skipped bool
+		var err error
+		from, skipped, err = suggestAdoptionRepo(binaryName)
+		if err != nil || skipped {
+			return err
+		}
+	}
+	parsedInput, err := stew.ParseCLIInput(from, "github")
+	if err != nil {
+		return err
+	}
+	if !parsedInput.IsGithubInput {
+		return stew.UnrecognizedInputError{}
+	}
+
+	sp := constants.LoadingSpinner
+	sp.Start()
+	releases, err := stew.GetReleases(stew.PackageData{Source: "github", Owner: parsedInput.Owner, Repo: parsedInput.Repo})
+	sp.Stop()
+	if err != nil {
+		return err
+	}
+
+	tag, asset := parsedInput.Tag, parsedInput.Asset
+	if tag == "" {
+		var inferred bool
+		tag, asset, inferred = stew.InferInstalledRelease(binaryPath, releases)
+		if inferred {
+			fmt.Printf("🔍 Inferred that %v is version %v\n", constants.GreenColor(binaryName), constants.GreenColor(tag))
+		} else {
+			tags := []string{}
+			for _, release := range releases {
+				tags = append(tags, release.TagName)
+			}
+			tag, err = stew.PromptSelect(fmt.Sprintf("Could not infer the version of %v. Choose its release tag:", binaryName), tags)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	release, err := stew.FindRelease(releases, tag)
+	if err != nil {
+		return err
+	}
+	if asset == "" {
+		assetNames := stew.GetReleaseAssetNames(release)
+		asset, err = stew.SelectAsset(assetNames, stew.PackageData{}, userOS, userArch)
+		if err != nil {
+			asset, err = stew.PromptSelect("Choose the asset that the binary came from:", assetNames)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	releaseAsset, err := stew.FindReleaseAsset(release, asset)
+	if err != nil {
+		return err
+	}
+
+	pkg, err := stew.AdoptBinary(systemInfo, stew.PackageData{
+		Source: "github",
+		Owner:  parsedInput.Owner,
+		Repo:   parsedInput.Repo,
+		Tag:    tag,
+		Asset:  asset,
+		Binary: binaryName,
+		URL:    releaseAsset.DownloadURL,
+	}, binaryPath)
+	if err != nil {
+		return err
+	}
+	lockFile.Packages = append(lockFile.Packages, pkg)
+	if err := stew.WriteLockFileJSON(*lockFile, systemInfo.StewLockFilePath); err != nil {
+		return err
+	}
+	recordHistory(systemInfo, newPackageHistoryEntry(systemInfo, "adopt", stew.PackageData{}, pkg))
+
+	fmt.Printf(
+		"✨ Adopted the %v binary from %v\n",
+		constants.GreenColor(binaryName),
+		constants.GreenColor(fmt.Sprintf("%v/%v@%v", pkg.Owner, pkg.Repo, pkg.Tag)),
+	)
+	if filepath.Dir(binaryPath) != systemInfo.StewBinPath {
+		fmt.Printf(
+			"💡 The original binary is still in %v. Delete it so that it doesn't shadow the one in %v\n",
+			constants.YellowColor(binaryPath),
+			constants.YellowColor(systemInfo.StewBinPath),
+		)
+	}
+	return nil
+}
+
+// suggestAdoptionRepo searches GitHub for repos named like a binary and lets the user pick the one it came from
+func suggestAdoptionRepo(binaryName string) (string, bool, error) {
+	if err := stew.ValidateGithubSearchQuery(binaryName); err != nil {
+		return "", false, err
+	}
+	sp := constants.LoadingSpinner
+	sp.Start()
+	searchResults, err := stew.NewGithubSearch(binaryName)
+	sp.Stop()
+	if err != nil {
+		return "", false, err
+	}
+	if len(searchResults.Items) == 0 {
+		return "", false, stew.NoGithubSearchResultsError{SearchQuery: binaryName}
+	}
+
+	searchResults = stew.RankSearchResults(binaryName, searchResults)
+	if len(searchResults.Items) > maxAdoptionSuggestions {
+		searchResults.Items = searchResults.Items[:maxAdoptionSuggestions]
+	}
+	const skip = "Skip this binary"
+	options := append(stew.FormatSearchResults(searchResults), skip)
+	choice, err := stew.PromptSelect(fmt.Sprintf("Choose the repo that %v came from:", binaryName), options)
+	if err != nil {
+		return "", false, err
+	}
+	if choice == skip {
+		return "", true, nil
+	}
+	searchResultIndex, _ := stew.Contains(options, choice)
+	return searchResults.Items[searchResultIndex].FullName, false, nil
+}
@@ -49,6 +49,7 @@ func handleGitlab(host string, groups []string, repo string, systemInfo stew.Sys
 	stewLockFilePath := systemInfo.StewLockFilePath
 	stewBinPath := systemInfo.StewBinPath
 	stewPkgPath := systemInfo.StewPkgPath
+	stewAssetPath := systemInfo.StewAssetPath
 	lockFile, err := stew.NewLockFile(stewLockFilePath, userOS, userArch)
 	stew.CatchAndExit(err)
 	sp := constants.LoadingSpinner
@@ -70,12 +71,12 @@ func handleGitlab(host string, groups []string, repo string, systemInfo stew.Sys
 	assetIndex, _ := stew.Contains(releaseAssets, asset)
 
 	downloadURL := gitlabProject.Releases[tagIndex].Assets.Links[assetIndex].DownloadURL
-	downloadPath := filepath.Join(stewPkgPath, asset)
+	downloadPath := filepath.Join(stewAssetPath, asset)
 	err = stew.DownloadFile(downloadPath, downloadURL, "gitea")
 	stew.CatchAndExit(err)
-	fmt.Printf("✅ Downloaded %v to %v\n", constants.GreenColor(asset), constants.GreenColor(stewPkgPath))
+	fmt.Printf("✅ Downloaded %v to %v\n", constants.GreenColor(asset), constants.GreenColor(stewAssetPath))
 
-	binaryName, err := stew.InstallBinary(downloadPath, repo, systemInfo, &lockFile, false)
+	binaryName, _, err := stew.InstallPackageBinary(downloadPath, repo, stew.PackageData{Tag: tag}, systemInfo, &lockFile, false)
 	if err != nil {
 		os.RemoveAll(downloadPath)
 		stew.CatchAndExit(err)
@@ -98,8 +99,15 @@ func handleGitlab(host string, groups []string, repo string, systemInfo stew.Sys
 		Host:   host,
 	}
 
+	packageData.BinarySHA256, err = stew.InstalledBinarySHA256(stewPkgPath, packageData)
+	stew.CatchAndExit(err)
+
 	lockFile.Packages = append(lockFile.Packages, packageData)
 
+	err = stew.RecordInstalledVersion(stewPkgPath, packageData)
+	stew.CatchAndExit(err)
+	recordHistory(systemInfo, newPackageHistoryEntry(systemInfo, "install", stew.PackageData{}, packageData))
+
 	err = stew.WriteLockFileJSON(lockFile, stewLockFilePath)
 	stew.CatchAndExit(err)
 
@@ -114,6 +122,7 @@ func handleGitea(host, owner, repo string, systemInfo stew.SystemInfo, userOS, u
 	stewLockFilePath := systemInfo.StewLockFilePath
 	stewBinPath := systemInfo.StewBinPath
 	stewPkgPath := systemInfo.StewPkgPath
+	stewAssetPath := systemInfo.StewAssetPath
 	lockFile, err := stew.NewLockFile(stewLockFilePath, userOS, userArch)
 	stew.CatchAndExit(err)
 	sp := constants.LoadingSpinner
@@ -135,12 +144,12 @@ func handleGitea(host, owner, repo string, systemInfo stew.SystemInfo, userOS, u
 	assetIndex, _ := stew.Contains(releaseAssets, asset)
 
 	downloadURL := giteaProject.Releases[tagIndex].Assets[assetIndex].DownloadURL
-	downloadPath := filepath.Join(stewPkgPath, asset)
+	downloadPath := filepath.Join(stewAssetPath, asset)
 	err = stew.DownloadFile(downloadPath, downloadURL, "gitea")
 	stew.CatchAndExit(err)
-	fmt.Printf("✅ Downloaded %v to %v\n", constants.GreenColor(asset), constants.GreenColor(stewPkgPath))
+	fmt.Printf("✅ Downloaded %v to %v\n", constants.GreenColor(asset), constants.GreenColor(stewAssetPath))
 
-	binaryName, err := stew.InstallBinary(downloadPath, repo, systemInfo, &lockFile, false)
+	binaryName, _, err := stew.InstallPackageBinary(downloadPath, repo, stew.PackageData{Tag: tag}, systemInfo, &lockFile, false)
 	if err != nil {
 		os.RemoveAll(downloadPath)
 		stew.CatchAndExit(err)
@@ -157,8 +166,15 @@ func handleGitea(host, owner, repo string, systemInfo stew.SystemInfo, userOS, u
 		Host:   host,
 	}
 
+	packageData.BinarySHA256, err = stew.InstalledBinarySHA256(stewPkgPath, packageData)
+	stew.CatchAndExit(err)
+
 	lockFile.Packages = append(lockFile.Packages, packageData)
 
+	err = stew.RecordInstalledVersion(stewPkgPath, packageData)
+	stew.CatchAndExit(err)
+	recordHistory(systemInfo, newPackageHistoryEntry(systemInfo, "install", stew.PackageData{}, packageData))
+
 	err = stew.WriteLockFileJSON(lockFile, stewLockFilePath)
 	stew.CatchAndExit(err)
 
@@ -173,6 +189,7 @@ func handleGithub(owner, repo string, systemInfo stew.SystemInfo, userOS, userAr
 	stewLockFilePath := systemInfo.StewLockFilePath
 	stewBinPath := systemInfo.StewBinPath
 	stewPkgPath := systemInfo.StewPkgPath
+	stewAssetPath := systemInfo.StewAssetPath
 	lockFile, err := stew.NewLockFile(stewLockFilePath, userOS, userArch)
 	stew.CatchAndExit(err)
 	sp := constants.LoadingSpinner
@@ -194,12 +211,12 @@ func handleGithub(owner, repo string, systemInfo stew.SystemInfo, userOS, userAr
 	assetIndex, _ := stew.Contains(releaseAssets, asset)
 
 	downloadURL := githubProject.Releases[tagIndex].Assets[assetIndex].DownloadURL
-	downloadPath := filepath.Join(stewPkgPath, asset)
+	downloadPath := filepath.Join(stewAssetPath, asset)
 	err = stew.DownloadFile(downloadPath, downloadURL, "github")
 	stew.CatchAndExit(err)
-	fmt.Printf("✅ Downloaded %v to %v\n", constants.GreenColor(asset), constants.GreenColor(stewPkgPath))
+	fmt.Printf("✅ Downloaded %v to %v\n", constants.GreenColor(asset), constants.GreenColor(stewAssetPath))
 
-	binaryName, err := stew.InstallBinary(downloadPath, repo, systemInfo, &lockFile, false)
+	binaryName, _, err := stew.InstallPackageBinary(downloadPath, repo, stew.PackageData{Tag: tag}, systemInfo, &lockFile, false)
 	if err != nil {
 		os.RemoveAll(downloadPath)
 		stew.CatchAndExit(err)
@@ -216,8 +233,15 @@ func handleGithub(owner, repo string, systemInfo stew.SystemInfo, userOS, userAr
 		Host:   "github.com",
 	}
 
+	packageData.BinarySHA256, err = stew.InstalledBinarySHA256(stewPkgPath, packageData)
+	stew.CatchAndExit(err)
+
 	lockFile.Packages = append(lockFile.Packages, packageData)
 
+	err = stew.RecordInstalledVersion(stewPkgPath, packageData)
+	stew.CatchAndExit(err)
+	recordHistory(systemInfo, newPackageHistoryEntry(systemInfo, "install", stew.PackageData{}, packageData))
+
 	err = stew.WriteLockFileJSON(lockFile, stewLockFilePath)
 	stew.CatchAndExit(err)
 
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/marwanhawari/stew/constants"
+	stew "github.com/marwanhawari/stew/lib"
+)
+
+// Changelog is executed when you run `stew changelog`
+func Changelog(changelogAllCliFlag bool, binaryName string) {
+	userOS, userArch, _, systemInfo, err := stew.Initialize()
+	stew.CatchAndExit(err)
+
+	if changelogAllCliFlag && binaryName != "" {
+		stew.CatchAndExit(stew.CLIFlagAndInputError{})
+	} else if !changelogAllCliFlag {
+		err := stew.ValidateCLIInput(binaryName)
+		stew.CatchAndExit(err)
+	}
+
+	lockFile, err := stew.NewLockFile(systemInfo.StewLockFilePath, userOS, userArch)
+	stew.CatchAndExit(err)
+
+	if len(lockFile.Packages) == 0 {
+		stew.CatchAndExit(stew.NoBinariesInstalledError{})
+	}
+
+	if !changelogAllCliFlag {
+		indexInLockFile, binaryFoundInLockFile := stew.FindBinaryInLockFile(lockFile, binaryName)
+		if !binaryFoundInLockFile {
+			stew.CatchAndExit(stew.BinaryNotInstalledError{Binary: binaryName})
+		}
+		err := changelogOne(lockFile.Packages[indexInLockFile])
+		stew.CatchAndExit(err)
+		return
+	}
+
+	for _, pkg := range lockFile.Packages {
+		// Packages from Stewfile groups that are no longer selected aren't upgraded by stew upgrade --all either
+		if !stew.InSelectedGroups(pkg, lockFile.SelectedGroups) {
+			continue
+		}
+		if err := changelogOne(pkg); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+}
+
+// changelogOne prints the release notes between the installed tag of a package and the tag stew upgrade would pick
+func changelogOne(pkg stew.PackageData) error {
+	fmt.Println(constants.GreenColor(pkg.Binary))
+	if pkg.Source == "other" {
+		return stew.InstalledFromURLError{Binary: pkg.Binary}
+	}
+
+	sp := constants.LoadingSpinner
+	sp.Start()
+	releases, err := stew.GetReleases(pkg)
+	sp.Stop()
+	if err != nil {
+		return err
+	}
+
+	releaseTags := []string{}
+	for _, release := range releases {
+		releaseTags = append(releaseTags, release.TagName)
+	}
+	upgradeSpec := pkg
+	upgradeSpec.Tag = ""
+	tag, err := stew.SelectTag(releaseTags, upgradeSpec)
+	if err != nil {
+		return err
+	}
+	if pkg.Tag == tag {
+		fmt.Printf("%v is already the latest version\n", constants.GreenColor(tag))
+		return nil
+	}
+	printChangelog(pkg, releases, tag)
+	return nil
+}
+
+// printChangelog prints the release notes of every release after the installed tag of a package up to the target tag
+func printChangelog(pkg stew.PackageData, releases []stew.Release, targetTag string) {
+	changelog, err := stew.ChangelogReleases(releases, pkg, targetTag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	fmt.Printf("📋 Changes from %v to %v\n", constants.YellowColor(pkg.Tag), constants.GreenColor(targetTag))
+	for _, release := range changelog {
+		printReleaseInfo(*stew.NewReleaseInfo(release), "")
+	}
+	fmt.Println()
+}
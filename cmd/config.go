@@ -31,7 +31,17 @@ func Config() {
 	newStewPath, newStewBinPath, err := stew.PromptConfig(defaultStewPath, defaultStewBinPath)
 	stew.CatchAndExit(err)
 
-	newStewConfig := stew.StewConfig{StewPath: newStewPath, StewBinPath: newStewBinPath}
+	existingStewConfig, err := stew.ReadStewConfigFile(stewConfigFilePath)
+	stew.CatchAndExit(err)
+
+	newStewLockFilePath, err := stew.ResolveStewLockFilePath(existingStewConfig.StewLockFilePath, newStewPath)
+	stew.CatchAndExit(err)
+
+	newStewConfig := stew.StewConfig{
+		StewPath:         newStewPath,
+		StewBinPath:      newStewBinPath,
+		StewLockFilePath: newStewLockFilePath,
+	}
 	err = stew.WriteStewConfigJSON(newStewConfig, stewConfigFilePath)
 	stew.CatchAndExit(err)
 
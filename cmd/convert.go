@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"fmt"
+
+	stew "github.com/marwanhawari/stew/lib"
+)
+
+// Convert is executed when you run `stew convert`
+func Convert(stewfilePath string) {
+	err := stew.ValidateCLIInput(stewfilePath)
+	stew.CatchAndExit(err)
+
+	packages, err := stew.ReadStewfileContents(stewfilePath)
+	stew.CatchAndExit(err)
+
+	contents, err := stew.ConvertStewfile(packages)
+	stew.CatchAndExit(err)
+
+	fmt.Print(contents)
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/marwanhawari/stew/constants"
+	stew "github.com/marwanhawari/stew/lib"
+)
+
+// Doctor is executed when you run `stew doctor`
+func Doctor(fixCliFlag bool) {
+	userOS := runtime.GOOS
+	userArch := runtime.GOARCH
+
+	// The config is read without Initialize, so that a broken config is reported instead of prompted for
+	stewConfigFilePath, err := stew.GetStewConfigFilePath(userOS)
+	stew.CatchAndExit(err)
+	stewConfig, findings := stew.DiagnoseConfig(stewConfigFilePath)
+	if len(findings) > 0 {
+		stewConfig.StewPath, err = stew.GetDefaultStewPath(userOS)
+		stew.CatchAndExit(err)
+		stewConfig.StewBinPath, err = stew.GetDefaultStewBinPath(userOS)
+		stew.CatchAndExit(err)
+	}
+	systemInfo, err := stew.ResolveSystemInfo(stewConfig)
+	stew.CatchAndExit(err)
+
+	lockFileFindings, err := stew.DiagnoseInstallation(systemInfo, userOS, userArch, os.Getenv("PATH"))
+	stew.CatchAndExit(err)
+	findings = append(findings, lockFileFindings...)
+
+	problems := 0
+	for _, finding := range findings {
+		icon := "ℹ️ "
+		problem := finding.Problem
+		switch finding.Severity {
+		case stew.DoctorError:
+			icon, problem = "❌", constants.RedColor(problem)
+		case stew.DoctorWarning:
+			icon, problem = "⚠️ ", constants.YellowColor(problem)
+		}
+		fmt.Printf("%v %v\n", icon, problem)
+
+		if fixCliFlag && finding.CanFix() {
+			if err := finding.ApplyFix(); err != nil {
+				fmt.Printf("   %v %v\n", constants.RedColor("Could not fix it:"), err)
+				problems++
+			} else {
+				fmt.Printf("   ✅ Fixed: %v\n", finding.Fix)
+			}
+			continue
+		}
+		if finding.CanFix() {
+			fmt.Printf("   💡 %v (stew doctor --fix)\n", finding.Fix)
+		} else {
+			fmt.Printf("   💡 %v\n", finding.Fix)
+		}
+		if finding.Severity != stew.DoctorInfo {
+			problems++
+		}
+	}
+
+	if problems == 0 {
+		fmt.Printf("✨ No problems found in %v\n", constants.GreenColor(systemInfo.StewPath))
+		return
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/marwanhawari/stew/constants"
+	stew "github.com/marwanhawari/stew/lib"
+)
+
+// Env is executed when you run `stew env`
+func Env(shell string, initCliFlag bool) {
+	workingDir, err := os.Getwd()
+	stew.CatchAndExit(err)
+
+	if initCliFlag {
+		projectStewPath, err := stew.InitProjectEnvironment(workingDir)
+		stew.CatchAndExit(err)
+		fmt.Fprintf(os.Stderr, "✨ Created a stew environment in %v\n", constants.GreenColor(projectStewPath))
+	}
+
+	projectStewPath, found, err := stew.FindProjectStewPath(workingDir)
+	stew.CatchAndExit(err)
+	if !found {
+		stew.CatchAndExit(stew.NoProjectEnvironmentError{})
+	}
+
+	pathAddition, err := stew.FormatPathAddition(stew.NewProjectSystemInfo(projectStewPath).StewBinPath, shell)
+	stew.CatchAndExit(err)
+	fmt.Println(pathAddition)
+}
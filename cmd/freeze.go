@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"fmt"
+
+	stew "github.com/marwanhawari/stew/lib"
+)
+
+// Freeze is executed when you run `stew freeze`
+func Freeze(noTagsFlag, noAssetsFlag bool) {
+	userOS, userArch, _, systemInfo, err := stew.Initialize()
+	stew.CatchAndExit(err)
+
+	lockFile, err := stew.NewLockFile(systemInfo.StewLockFilePath, userOS, userArch)
+	stew.CatchAndExit(err)
+
+	for _, pkg := range lockFile.Packages {
+		fmt.Println(stew.FormatStewfileLine(pkg, !noTagsFlag, !noAssetsFlag))
+	}
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/marwanhawari/stew/constants"
+	stew "github.com/marwanhawari/stew/lib"
+)
+
+// GC is executed when you run `stew gc`
+func GC(dryRun, pruneVersionsCliFlag bool) {
+	userOS, userArch, _, systemInfo, err := stew.Initialize()
+	stew.CatchAndExit(err)
+
+	lockFile, err := stew.NewLockFile(systemInfo.StewLockFilePath, userOS, userArch)
+	stew.CatchAndExit(err)
+	history, err := stew.ReadRollbackHistory(systemInfo.StewPath)
+	stew.CatchAndExit(err)
+
+	garbage, err := stew.FindGarbage(systemInfo, lockFile, history, pruneVersionsCliFlag)
+	stew.CatchAndExit(err)
+	if len(garbage) == 0 {
+		fmt.Printf("✨ Nothing to clean up in %v\n", constants.GreenColor(systemInfo.StewPath))
+		return
+	}
+
+	var reclaimed int64
+	for _, garbageFile := range garbage {
+		if dryRun {
+			fmt.Printf("Would delete %v (%v)\n", garbageFile.Path, stew.FormatSize(garbageFile.Size))
+			reclaimed += garbageFile.Size
+			continue
+		}
+		if err := os.RemoveAll(garbageFile.Path); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		fmt.Printf("🗑️  Deleted %v (%v)\n", garbageFile.Path, stew.FormatSize(garbageFile.Size))
+		reclaimed += garbageFile.Size
+	}
+
+	if dryRun {
+		fmt.Printf("Would reclaim %v\n", constants.GreenColor(stew.FormatSize(reclaimed)))
+		return
+	}
+	fmt.Printf("✨ Reclaimed %v\n", constants.GreenColor(stew.FormatSize(reclaimed)))
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/marwanhawari/stew/constants"
+	stew "github.com/marwanhawari/stew/lib"
+)
+
+// History is executed when you run `stew history`
+func History(binaryName string) {
+	_, _, _, systemInfo, err := stew.Initialize()
+	stew.CatchAndExit(err)
+
+	entries := readHistory(systemInfo, binaryName)
+
+	for _, entry := range entries {
+		fields := []string{
+			entry.Time.Local().Format("2006-01-02 15:04:05"),
+			entry.Operation,
+			constants.GreenColor(entry.Binary),
+		}
+		if entry.NewBinary != "" {
+			fields = append(fields, "→ "+constants.GreenColor(entry.NewBinary))
+		}
+		switch {
+		case entry.OldTag != "" && entry.NewTag != "":
+			fields = append(fields, entry.OldTag+" → "+entry.NewTag)
+		case entry.NewTag != "":
+			fields = append(fields, entry.NewTag)
+		case entry.OldTag != "":
+			fields = append(fields, entry.OldTag)
+		}
+		if entry.Asset != "" {
+			fields = append(fields, entry.Asset)
+		}
+		if entry.Result == stew.HistoryResultSuccess {
+			fields = append(fields, "✅")
+		} else {
+			fields = append(fields, constants.RedColor(entry.Result))
+		}
+		fmt.Println(strings.Join(fields, "  "))
+	}
+}
+
+// readHistory reads the entries of the history journal for a binary, or for every binary if none is given.
+// Corrupt lines of the journal are reported and skipped.
+func readHistory(systemInfo stew.SystemInfo, binary string) []stew.HistoryEntry {
+	entries, errs, err := stew.ReadHistory(systemInfo.StewPath, binary)
+	stew.CatchAndExit(err)
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	return entries
+}
+
+// recordHistory appends an entry to the history journal. The operation has already happened at this point,
+// so failing to record it is only reported.
+func recordHistory(systemInfo stew.SystemInfo, entry stew.HistoryEntry) {
+	if err := stew.AppendHistory(systemInfo.StewPath, entry); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+// newPackageHistoryEntry creates a history entry for a change from one lockfile entry of a binary to another
+func newPackageHistoryEntry(systemInfo stew.SystemInfo, operation string, previousPkg, pkg stew.PackageData) stew.HistoryEntry {
+	entry := stew.NewHistoryEntry(operation, pkg.Binary, nil)
+	entry.OldTag = previousPkg.Tag
+	entry.NewTag = pkg.Tag
+	entry.Asset = pkg.Asset
+	// The digest is left out if the asset is not kept in the ~/.stew/assets path
+	if assetSHA256, err := stew.SHA256File(filepath.Join(systemInfo.StewAssetPath, pkg.Asset)); err == nil && pkg.Asset != "" {
+		entry.SHA256 = assetSHA256
+	}
+	return entry
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/marwanhawari/stew/constants"
+	stew "github.com/marwanhawari/stew/lib"
+)
+
+// Info is executed when you run `stew info`
+func Info(host, hostType string, jsonOutput bool, cliInput string) {
+	userOS, userArch, _, systemInfo, err := stew.Initialize()
+	stew.CatchAndExit(err)
+
+	err = stew.ValidateCLIInput(cliInput)
+	stew.CatchAndExit(err)
+
+	lockFile, err := stew.NewLockFile(systemInfo.StewLockFilePath, userOS, userArch)
+	stew.CatchAndExit(err)
+
+	pkg, installed, err := stew.ResolveInfoPackage(lockFile, cliInput, host, hostType)
+	stew.CatchAndExit(err)
+
+	info := stew.NewPackageInfo(pkg, installed, userOS, userArch)
+	if installed {
+		history := readHistory(systemInfo, pkg.Binary)
+		if installTime, found := stew.InstallTime(systemInfo.StewPkgPath, history, pkg); found {
+			info.InstalledAt = &installTime
+		}
+	}
+
+	if pkg.Source != "other" {
+		sp := constants.LoadingSpinner
+		if !jsonOutput {
+			sp.Start()
+		}
+		metadata, metadataErr := stew.GetRepoMetadata(pkg)
+		releases, releasesErr := stew.GetReleases(pkg)
+		sp.Stop()
+		// The lockfile entry of an installed package is still worth showing when its source can't be reached
+		if !installed {
+			stew.CatchAndExit(metadataErr)
+			stew.CatchAndExit(releasesErr)
+		}
+		if metadataErr == nil {
+			info.AddRepoMetadata(metadata)
+		} else {
+			fmt.Fprintln(os.Stderr, metadataErr)
+		}
+		if releasesErr == nil {
+			releasesErr = info.AddReleases(pkg, releases)
+		}
+		if releasesErr != nil {
+			fmt.Fprintln(os.Stderr, releasesErr)
+		}
+	}
+
+	if jsonOutput {
+		out, err := json.MarshalIndent(info, "", "  ")
+		stew.CatchAndExit(err)
+		fmt.Println(string(out))
+		return
+	}
+	printPackageInfo(info)
+}
+
+func printPackageInfo(info stew.PackageInfo) {
+	if info.Repo != "" {
+		fmt.Println(constants.GreenColor(info.Repo))
+	} else {
+		fmt.Println(constants.GreenColor(info.Binary))
+	}
+	printPackageField("Description", info.Description)
+	printPackageField("License", info.License)
+	printPackageField("Homepage", info.Homepage)
+	printPackageField("Source", info.Source)
+	printPackageField("Host", info.Host)
+	if !info.Installed {
+		printPackageField("Installed", "no")
+	}
+	printPackageField("Binary", info.Binary)
+	printPackageField("Tag", info.Tag)
+	if info.LatestTag != "" && info.LatestTag != info.Tag {
+		printPackageField("Latest tag", constants.YellowColor(info.LatestTag))
+	} else {
+		printPackageField("Latest tag", info.LatestTag)
+	}
+	printPackageField("Asset", info.Asset)
+	printPackageField("URL", info.URL)
+	printPackageField("Asset SHA256", info.AssetSHA256)
+	printPackageField("Binary SHA256", info.BinarySHA256)
+	if info.InstalledAt != nil {
+		printPackageField("Installed", info.InstalledAt.Local().Format("2006-01-02 15:04:05"))
+	}
+
+	if info.InstalledRelease != nil && info.InstalledRelease.Tag != info.LatestTag {
+		printReleaseInfo(*info.InstalledRelease, "installed")
+	}
+	if info.LatestRelease != nil {
+		label := "latest"
+		if info.LatestRelease.Tag == info.Tag {
+			label = "installed, latest"
+		}
+		printReleaseInfo(*info.LatestRelease, label)
+	}
+}
+
+func printReleaseInfo(release stew.ReleaseInfo, label string) {
+	fmt.Println()
+	heading := "📝 " + constants.GreenColor(release.Tag)
+	if label != "" {
+		heading += fmt.Sprintf(" (%v)", label)
+	}
+	if release.PublishedAt != nil {
+		heading += " released " + release.PublishedAt.Local().Format("2006-01-02")
+	}
+	fmt.Println(heading)
+	if release.Notes == "" {
+		fmt.Println("  No release notes")
+		return
+	}
+	for _, line := range strings.Split(stew.RenderMarkdown(release.Notes), "\n") {
+		fmt.Println(strings.TrimRight("  "+line, " "))
+	}
+}
@@ -10,47 +10,92 @@ import (
 	stew "github.com/marwanhawari/stew/lib"
 )
 
+// InstallOptions contains the options for `stew install`
+type InstallOptions struct {
+	Host     string
+	HostType string
+	OS       string
+	Arch     string
+	BinPath  string
+	LockOnly bool
+	Frozen   bool
+	// Overwrite replaces an installed binary with the same name without prompting
+	Overwrite bool
+	// Groups limits a Stewfile install to the entries of these groups and the entries without a group
+	Groups []string
+	// Spec is the Stewfile entry being installed, which can constrain the tag, asset and binary
+	Spec stew.PackageData
+	// NoKeepAsset deletes the downloaded asset from the ~/.stew/assets path once the binary is installed
+	NoKeepAsset bool
+}
+
+// withHost returns a copy of the options which targets a different host
+func (opts InstallOptions) withHost(host, hostType string) InstallOptions {
+	opts.Host = host
+	opts.HostType = hostType
+	return opts
+}
+
 // Install is executed when you run `stew install`
-func Install(host, hostType string, cliInputs []string) {
-	fmt.Println(host, hostType, cliInputs)
+func Install(cliInputs []string, opts InstallOptions) {
 	var err error
+	host := opts.Host
+	hostType := opts.HostType
 
 	userOS, userArch, _, systemInfo, err := stew.Initialize()
 	stew.CatchAndExit(err)
 
+	targetOS, targetArch := userOS, userArch
+	if opts.OS != "" {
+		targetOS = opts.OS
+	}
+	if opts.Arch != "" {
+		targetArch = opts.Arch
+	}
+	err = stew.ValidatePlatform(targetOS, targetArch)
+	stew.CatchAndExit(err)
+
+	// A frozen install only installs what is already locked, so there is nothing for --lock-only to do
+	if opts.Frozen && opts.LockOnly {
+		stew.CatchAndExit(stew.FrozenLockFileError{Package: strings.Join(cliInputs, " "), Reason: "--lock-only can't be used with --frozen"})
+	}
+
+	isCrossPlatform := targetOS != userOS || targetArch != userArch
+	switch {
+	case opts.BinPath != "":
+		systemInfo, err = stew.NewTargetSystemInfo(systemInfo, opts.BinPath, targetOS, targetArch)
+		stew.CatchAndExit(err)
+	case opts.LockOnly:
+		systemInfo.StewLockFilePath, err = stew.ResolvePath("Stewfile.lock.json")
+		stew.CatchAndExit(err)
+	case isCrossPlatform:
+		stew.CatchAndExit(stew.CrossPlatformInstallError{OS: targetOS, Arch: targetArch})
+	}
+
+	if opts.Frozen {
+		for _, cliInput := range cliInputs {
+			installFrozen(cliInput, targetOS, targetArch, systemInfo, opts)
+		}
+		return
+	}
+
 	for _, cliInput := range cliInputs {
 		if strings.Contains(cliInput, "Stewfile.lock.json") {
-			packages, err := stew.ReadStewLockFileContents(cliInput)
+			inputLockFile, err := stew.ReadStewLockFile(cliInput)
 			stew.CatchAndExit(err)
-			for _, packageData := range packages {
-				switch packageData.Source {
-				case "other":
-					Install("", "", []string{packageData.URL})
-				case "gitea":
-					Install(
-						packageData.Host,
-						"gitea",
-						[]string{
-							packageData.Owner + "/" + packageData.Repo + "@" + packageData.Tag + "#" + packageData.Asset,
-						},
-					)
-				case "gitlab":
-					Install(
-						packageData.Host,
-						"gitlab",
-						[]string{
-							packageData.Owner + "/" + packageData.Repo + "@" + packageData.Tag + "#" + packageData.Asset,
-						},
-					)
-				default:
-					Install(
-						"",
-						"github",
-						[]string{
-							packageData.Owner + "/" + packageData.Repo + "@" + packageData.Tag + "#" + packageData.Asset,
-						},
-					)
+			for _, packageData := range inputLockFile.Packages {
+				if !stew.MatchesPlatform(packageData, targetOS, targetArch) {
+					printSkippedPlatform(packageData, targetOS, targetArch)
+					continue
 				}
+				// Pick the asset that was locked for the target platform, otherwise let it be detected again
+				platformData, _ := stew.FindPlatformData(inputLockFile, packageData, targetOS, targetArch)
+				packageData.Asset = platformData.Asset
+				if platformData.URL != "" {
+					packageData.URL = platformData.URL
+				}
+				reference := stew.GetPackageReference(packageData)
+				Install([]string{reference.Input}, opts.withHost(reference.Host, reference.HostType))
 			}
 			return
 		}
@@ -58,41 +103,19 @@ func Install(host, hostType string, cliInputs []string) {
 		if strings.Contains(cliInput, "Stewfile") {
 			packages, err := stew.ReadStewfileContents(cliInput)
 			stew.CatchAndExit(err)
+			packages, err = stew.FilterStewfileGroups(packages, opts.Groups)
+			stew.CatchAndExit(err)
 			for _, packageData := range packages {
-				fmt.Printf("%+v\n", packageData)
-				switch packageData.Source {
-				case "other":
-					Install("", "", []string{packageData.URL})
-				case "gitlab":
-					groupString := ""
-					for _, group := range packageData.Groups {
-						groupString += group + "/"
-					}
-					groupString = strings.TrimSuffix(groupString, "/")
-					Install(
-						host,
-						"gitlab",
-						[]string{
-							groupString + "/" + packageData.Repo + "@" + packageData.Tag + "#" + packageData.Asset,
-						},
-					)
-				case "gitea":
-					Install(
-						packageData.Host,
-						"gitea",
-						[]string{
-							packageData.Owner + "/" + packageData.Repo + "@" + packageData.Tag + "#" + packageData.Asset,
-						},
-					)
-				default:
-					Install(
-						"",
-						"github",
-						[]string{
-							packageData.Owner + "/" + packageData.Repo + "@" + packageData.Tag + "#" + packageData.Asset,
-						},
-					)
+				if !stew.MatchesPlatform(packageData, targetOS, targetArch) {
+					printSkippedPlatform(packageData, targetOS, targetArch)
+					continue
 				}
+				installStewfilePackage(packageData, opts)
+			}
+			// The recorded selection only changes when groups are selected explicitly
+			if len(opts.Groups) > 0 {
+				err = recordSelectedGroups(systemInfo.StewLockFilePath, targetOS, targetArch, opts.Groups)
+				stew.CatchAndExit(err)
 			}
 			return
 		}
@@ -107,6 +130,7 @@ func Install(host, hostType string, cliInputs []string) {
 
 		stewBinPath := systemInfo.StewBinPath
 		stewPkgPath := systemInfo.StewPkgPath
+		stewAssetPath := systemInfo.StewAssetPath
 		stewLockFilePath := systemInfo.StewLockFilePath
 		stewTmpPath := systemInfo.StewTmpPath
 
@@ -120,8 +144,10 @@ func Install(host, hostType string, cliInputs []string) {
 		asset := parsedInput.Asset
 		downloadURL := parsedInput.DownloadURL
 
-		lockFile, err := stew.NewLockFile(stewLockFilePath, userOS, userArch)
+		lockFile, err := stew.NewLockFile(stewLockFilePath, targetOS, targetArch)
 		stew.CatchAndExit(err)
+		lockFile.Os = targetOS
+		lockFile.Arch = targetArch
 
 		err = os.RemoveAll(stewTmpPath)
 		stew.CatchAndExit(err)
@@ -142,7 +168,8 @@ func Install(host, hostType string, cliInputs []string) {
 				stew.CatchAndExit(err)
 
 				if tag == "" || tag == "latest" {
-					tag = giteaProject.Releases[0].TagName
+					tag, err = stew.SelectTag(releaseTags, opts.Spec)
+					stew.CatchAndExit(err)
 				}
 
 				// Need to make sure user input tag is in the tags
@@ -163,8 +190,8 @@ func Install(host, hostType string, cliInputs []string) {
 				releaseAssets, err := stew.GetGiteaReleasesAssets(giteaProject, tag)
 				stew.CatchAndExit(err)
 
-				if asset == "" {
-					asset, err = stew.DetectAsset(userOS, userArch, releaseAssets)
+				if asset == "" || asset == opts.Spec.Asset {
+					asset, err = stew.SelectAsset(releaseAssets, opts.Spec, targetOS, targetArch)
 				}
 				stew.CatchAndExit(err)
 
@@ -196,7 +223,8 @@ func Install(host, hostType string, cliInputs []string) {
 				stew.CatchAndExit(err)
 
 				if tag == "" || tag == "latest" {
-					tag = gitlabProject.Releases[0].TagName
+					tag, err = stew.SelectTag(releaseTags, opts.Spec)
+					stew.CatchAndExit(err)
 				}
 
 				// Need to make sure user input tag is in the tags
@@ -217,8 +245,8 @@ func Install(host, hostType string, cliInputs []string) {
 				releaseAssets, err := stew.GetGitlabReleasesAssets(gitlabProject, tag)
 				stew.CatchAndExit(err)
 
-				if asset == "" {
-					asset, err = stew.DetectAsset(userOS, userArch, releaseAssets)
+				if asset == "" || asset == opts.Spec.Asset {
+					asset, err = stew.SelectAsset(releaseAssets, opts.Spec, targetOS, targetArch)
 				}
 				stew.CatchAndExit(err)
 
@@ -251,7 +279,8 @@ func Install(host, hostType string, cliInputs []string) {
 				stew.CatchAndExit(err)
 
 				if tag == "" || tag == "latest" {
-					tag = githubProject.Releases[0].TagName
+					tag, err = stew.SelectTag(releaseTags, opts.Spec)
+					stew.CatchAndExit(err)
 				}
 
 				// Need to make sure user input tag is in the tags
@@ -272,8 +301,8 @@ func Install(host, hostType string, cliInputs []string) {
 				releaseAssets, err := stew.GetGithubReleasesAssets(githubProject, tag)
 				stew.CatchAndExit(err)
 
-				if asset == "" {
-					asset, err = stew.DetectAsset(userOS, userArch, releaseAssets)
+				if asset == "" || asset == opts.Spec.Asset {
+					asset, err = stew.SelectAsset(releaseAssets, opts.Spec, targetOS, targetArch)
 				}
 				stew.CatchAndExit(err)
 
@@ -293,15 +322,36 @@ func Install(host, hostType string, cliInputs []string) {
 		} else {
 			fmt.Println(constants.GreenColor(asset))
 		}
-		downloadPath := filepath.Join(stewPkgPath, asset)
-		err = stew.DownloadFile(downloadPath, downloadURL, hostType)
-		stew.CatchAndExit(err)
-		fmt.Printf("✅ Downloaded %v to %v\n", constants.GreenColor(asset), constants.GreenColor(stewPkgPath))
-
-		binaryName, err := stew.InstallBinary(downloadPath, repo, systemInfo, &lockFile, false)
-		if err != nil {
-			os.RemoveAll(downloadPath)
+		var binaryName string
+		var extraFiles []string
+		var previousPkg stew.PackageData
+		if !opts.LockOnly {
+			downloadPath := filepath.Join(stewAssetPath, asset)
+			err = stew.DownloadFile(downloadPath, downloadURL, hostType)
 			stew.CatchAndExit(err)
+			fmt.Printf("✅ Downloaded %v to %v\n", constants.GreenColor(asset), constants.GreenColor(stewAssetPath))
+
+			spec := opts.Spec
+			spec.Tag = tag
+			previousPackages := append([]stew.PackageData{}, lockFile.Packages...)
+			binaryName, extraFiles, err = stew.InstallPackageBinary(downloadPath, repo, spec, systemInfo, &lockFile, opts.Overwrite)
+			if err != nil {
+				os.RemoveAll(downloadPath)
+				if _, aborted := err.(stew.AbortBinaryOverwriteError); !aborted {
+					entry := stew.NewHistoryEntry("install", repo, err)
+					entry.NewTag, entry.Asset = tag, asset
+					recordHistory(systemInfo, entry)
+				}
+				stew.CatchAndExit(err)
+			}
+			if indexInPreviousPackages, found := stew.FindBinaryInLockFile(stew.LockFile{Packages: previousPackages}, binaryName); found {
+				previousPkg = previousPackages[indexInPreviousPackages]
+			}
+			// Overwriting keeps the previous entry in the lockfile, so it has to be replaced here
+			if indexInLockFile, found := stew.FindBinaryInLockFile(lockFile, binaryName); opts.Overwrite && found {
+				lockFile.Packages, err = stew.RemovePackage(lockFile.Packages, indexInLockFile)
+				stew.CatchAndExit(err)
+			}
 		}
 
 		var packageData stew.PackageData
@@ -316,6 +366,9 @@ func Install(host, hostType string, cliInputs []string) {
 				URL:    downloadURL,
 				Host:   host,
 			}
+			if hostType == "gitlab" {
+				packageData.Groups = groups
+			}
 		} else {
 			packageData = stew.PackageData{
 				Source: "other",
@@ -328,11 +381,55 @@ func Install(host, hostType string, cliInputs []string) {
 			}
 		}
 
+		packageData.AssetPattern = opts.Spec.AssetPattern
+		packageData.Constraint = opts.Spec.Constraint
+		packageData.Channel = opts.Spec.Channel
+		packageData.ExtraFiles = opts.Spec.ExtraFiles
+		packageData.InstalledExtraFiles = extraFiles
+		packageData.Overrides = opts.Spec.Overrides
+		packageData.StewfileGroups = opts.Spec.StewfileGroups
+		packageData.OnlyOS = opts.Spec.OnlyOS
+		packageData.OnlyArch = opts.Spec.OnlyArch
+
+		if !opts.LockOnly {
+			packageData.BinarySHA256, err = stew.InstalledBinarySHA256(stewPkgPath, packageData)
+			stew.CatchAndExit(err)
+			packageData.ExtraFilesSHA256, err = stew.InstalledExtraFilesSHA256(stewPkgPath, packageData)
+			stew.CatchAndExit(err)
+		}
+
+		if opts.LockOnly {
+			// Nothing was installed, so replace any previously locked entry for the same package
+			if indexInLockFile, found := stew.FindPackageInLockFile(lockFile, packageData); found {
+				lockFile.Packages, err = stew.RemovePackage(lockFile.Packages, indexInLockFile)
+				stew.CatchAndExit(err)
+			}
+		}
+
 		lockFile.Packages = append(lockFile.Packages, packageData)
 
 		err = stew.WriteLockFileJSON(lockFile, stewLockFilePath)
 		stew.CatchAndExit(err)
 
+		if !opts.LockOnly {
+			err = stew.RecordInstalledVersion(stewPkgPath, packageData)
+			stew.CatchAndExit(err)
+			recordHistory(systemInfo, newPackageHistoryEntry(systemInfo, "install", previousPkg, packageData))
+			if opts.NoKeepAsset {
+				err = stew.DeleteCachedAsset(stewAssetPath, packageData.Asset)
+				stew.CatchAndExit(err)
+			}
+		}
+
+		if opts.LockOnly {
+			fmt.Printf(
+				"🔒 Locked %v for %v\n",
+				constants.GreenColor(asset),
+				constants.GreenColor(targetOS+"/"+targetArch),
+			)
+			continue
+		}
+
 		fmt.Printf(
 			"✨ Successfully installed the %v binary in %v\n",
 			constants.GreenColor(binaryName),
@@ -341,3 +438,135 @@ func Install(host, hostType string, cliInputs []string) {
 
 	}
 }
+
+// installFrozen installs exactly the assets recorded in a lockfile. A Stewfile input is installed from the
+// Stewfile.lock.json next to it. Nothing is re-resolved, nothing is prompted and the input lockfile is never modified.
+// Only the entries of the selected groups are installed.
+func installFrozen(cliInput, targetOS, targetArch string, systemInfo stew.SystemInfo, opts InstallOptions) {
+	stewBinPath := systemInfo.StewBinPath
+	stewPkgPath := systemInfo.StewPkgPath
+	stewAssetPath := systemInfo.StewAssetPath
+	stewLockFilePath := systemInfo.StewLockFilePath
+	stewTmpPath := systemInfo.StewTmpPath
+
+	inputLockFilePath := cliInput
+	var stewfilePackages []stew.PackageData
+	if !strings.Contains(cliInput, "Stewfile.lock.json") {
+		if !strings.Contains(cliInput, "Stewfile") {
+			stew.CatchAndExit(stew.FrozenLockFileError{Package: cliInput, Reason: "only a Stewfile or Stewfile.lock.json can be installed with --frozen"})
+		}
+		var err error
+		stewfilePackages, err = stew.ReadStewfileContents(cliInput)
+		stew.CatchAndExit(err)
+		inputLockFilePath = stew.StewfileLockFilePath(cliInput)
+	}
+
+	inputLockFile, err := stew.ReadStewLockFile(inputLockFilePath)
+	stew.CatchAndExit(err)
+	if stewfilePackages != nil {
+		_, err = stew.FilterStewfileGroups(stewfilePackages, opts.Groups)
+		stew.CatchAndExit(err)
+		err = stew.ValidateFrozenLockFile(stewfilePackages, inputLockFile, targetOS, targetArch, opts.Groups)
+		stew.CatchAndExit(err)
+	}
+	lockedPackages, err := stew.FilterStewfileGroups(inputLockFile.Packages, opts.Groups)
+	stew.CatchAndExit(err)
+
+	resolvedInputLockFilePath, err := stew.ResolvePath(inputLockFilePath)
+	stew.CatchAndExit(err)
+	installingFromStewLockFile := resolvedInputLockFilePath == stewLockFilePath
+
+	lockFile, err := stew.NewLockFile(stewLockFilePath, targetOS, targetArch)
+	stew.CatchAndExit(err)
+
+	for _, pkg := range lockedPackages {
+		if !stew.MatchesPlatform(pkg, targetOS, targetArch) {
+			printSkippedPlatform(pkg, targetOS, targetArch)
+			continue
+		}
+		platformData, err := stew.FindFrozenPlatformData(inputLockFile, pkg, targetOS, targetArch)
+		stew.CatchAndExit(err)
+		fmt.Println(constants.GreenColor(platformData.Asset))
+
+		err = os.RemoveAll(stewTmpPath)
+		stew.CatchAndExit(err)
+		err = os.MkdirAll(stewTmpPath, 0755)
+		stew.CatchAndExit(err)
+
+		downloadPath := filepath.Join(stewAssetPath, platformData.Asset)
+		err = stew.DownloadFile(downloadPath, platformData.URL, pkg.Source)
+		stew.CatchAndExit(err)
+		if err := stew.VerifySHA256(downloadPath, platformData.SHA256); err != nil {
+			os.RemoveAll(downloadPath)
+			stew.CatchAndExit(err)
+		}
+		fmt.Printf("✅ Downloaded %v to %v\n", constants.GreenColor(platformData.Asset), constants.GreenColor(stewAssetPath))
+
+		pkg.Asset = platformData.Asset
+		pkg.URL = platformData.URL
+		previousPackages := append([]stew.PackageData{}, lockFile.Packages...)
+		pkg.Binary, err = stew.InstallLockedBinary(downloadPath, pkg, systemInfo, &lockFile)
+		if err != nil {
+			os.RemoveAll(downloadPath)
+			entry := stew.NewHistoryEntry("install", pkg.Binary, err)
+			entry.NewTag, entry.Asset = pkg.Tag, pkg.Asset
+			recordHistory(systemInfo, entry)
+			stew.CatchAndExit(err)
+		}
+		pkg.BinarySHA256, err = stew.InstalledBinarySHA256(stewPkgPath, pkg)
+		stew.CatchAndExit(err)
+		lockFile.Packages = append(lockFile.Packages, pkg)
+
+		err = stew.RecordInstalledVersion(stewPkgPath, pkg)
+		stew.CatchAndExit(err)
+		var previousPkg stew.PackageData
+		if indexInPreviousPackages, found := stew.FindBinaryInLockFile(stew.LockFile{Packages: previousPackages}, pkg.Binary); found {
+			previousPkg = previousPackages[indexInPreviousPackages]
+		}
+		recordHistory(systemInfo, newPackageHistoryEntry(systemInfo, "install", previousPkg, pkg))
+		if opts.NoKeepAsset {
+			err = stew.DeleteCachedAsset(stewAssetPath, pkg.Asset)
+			stew.CatchAndExit(err)
+		}
+
+		fmt.Printf(
+			"✨ Successfully installed the %v binary in %v\n",
+			constants.GreenColor(pkg.Binary),
+			constants.GreenColor(stewBinPath),
+		)
+	}
+
+	if !installingFromStewLockFile {
+		if len(opts.Groups) > 0 {
+			lockFile.SelectedGroups = opts.Groups
+		}
+		err = stew.WriteLockFileJSON(lockFile, stewLockFilePath)
+		stew.CatchAndExit(err)
+	}
+}
+
+// installStewfilePackage installs a single entry that was read from a Stewfile
+func installStewfilePackage(packageData stew.PackageData, opts InstallOptions) {
+	opts.Spec = packageData
+	reference := stew.GetPackageReference(packageData)
+	Install([]string{reference.Input}, opts.withHost(reference.Host, reference.HostType))
+}
+
+// recordSelectedGroups saves the Stewfile groups that were installed so that later syncs and upgrades use the same selection
+func recordSelectedGroups(stewLockFilePath, targetOS, targetArch string, groups []string) error {
+	lockFile, err := stew.NewLockFile(stewLockFilePath, targetOS, targetArch)
+	if err != nil {
+		return err
+	}
+	lockFile.SelectedGroups = groups
+	return stew.WriteLockFileJSON(lockFile, stewLockFilePath)
+}
+
+// printSkippedPlatform reports a Stewfile entry that is restricted to other platforms
+func printSkippedPlatform(pkg stew.PackageData, targetOS, targetArch string) {
+	fmt.Printf(
+		"⏭️  Skipping %v, which is not used on %v\n",
+		constants.GreenColor(stew.FormatStewfileLine(pkg, false, false)),
+		constants.GreenColor(stew.PlatformKey(targetOS, targetArch)),
+	)
+}
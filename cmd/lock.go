@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/marwanhawari/stew/constants"
+	stew "github.com/marwanhawari/stew/lib"
+)
+
+// Lock is executed when you run `stew lock`
+func Lock(stewfilePath string, platforms []string) {
+	userOS, userArch, _, systemInfo, err := stew.Initialize()
+	stew.CatchAndExit(err)
+
+	for _, platform := range platforms {
+		_, _, err := stew.ParsePlatform(platform)
+		stew.CatchAndExit(err)
+	}
+
+	if stewfilePath != "" {
+		lockStewfile(stewfilePath, platforms, userOS, userArch, systemInfo)
+		return
+	}
+
+	stewLockFilePath := systemInfo.StewLockFilePath
+
+	lockFile, err := stew.NewLockFile(stewLockFilePath, userOS, userArch)
+	stew.CatchAndExit(err)
+
+	if len(lockFile.Packages) == 0 {
+		stew.CatchAndExit(stew.NoBinariesInstalledError{})
+	}
+
+	if len(platforms) == 0 {
+		platforms = []string{stew.PlatformKey(lockFile.Os, lockFile.Arch)}
+	}
+
+	for index, pkg := range lockFile.Packages {
+		fmt.Println(constants.GreenColor(pkg.Binary))
+		lockedPkg, err := stew.LockPackagePlatforms(pkg, lockFile, stew.FilterPlatforms(pkg, platforms), systemInfo.StewTmpPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		lockFile.Packages[index] = lockedPkg
+	}
+
+	err = stew.WriteLockFileJSON(lockFile, stewLockFilePath)
+	stew.CatchAndExit(err)
+}
+
+// lockStewfile resolves every entry of a Stewfile into a lockfile next to it without installing anything
+func lockStewfile(stewfilePath string, platforms []string, userOS, userArch string, systemInfo stew.SystemInfo) {
+	packages, err := stew.ReadStewfileContents(stewfilePath)
+	stew.CatchAndExit(err)
+
+	stewfileLockFilePath := stew.StewfileLockFilePath(stewfilePath)
+	previousLockFile, err := stew.NewLockFile(stewfileLockFilePath, userOS, userArch)
+	stew.CatchAndExit(err)
+
+	hostPlatform := stew.PlatformKey(userOS, userArch)
+	if _, found := stew.Contains(platforms, hostPlatform); !found {
+		platforms = append([]string{hostPlatform}, platforms...)
+	}
+
+	lockFile := stew.LockFile{Os: userOS, Arch: userArch, Packages: []stew.PackageData{}}
+	for _, pkg := range packages {
+		if pkg.Source == "other" {
+			fmt.Println(constants.GreenColor(pkg.URL))
+		} else {
+			fmt.Println(constants.GreenColor(pkg.Owner + "/" + pkg.Repo))
+		}
+
+		packagePlatforms := stew.FilterPlatforms(pkg, platforms)
+		if len(packagePlatforms) == 0 {
+			fmt.Printf("⏭️  Skipping %v, which is not used on any of the locked platforms\n", constants.GreenColor(stew.FormatStewfileLine(pkg, false, false)))
+			continue
+		}
+
+		var previousPkg stew.PackageData
+		if index, found := stew.FindPackageInLockFile(previousLockFile, pkg); found {
+			previousPkg = previousLockFile.Packages[index]
+		}
+
+		lockedPkg, err := stew.LockStewfilePackage(pkg, previousPkg, lockFile, packagePlatforms, systemInfo.StewTmpPath)
+		stew.CatchAndExit(err)
+		lockFile.Packages = append(lockFile.Packages, lockedPkg)
+	}
+
+	err = stew.WriteLockFileJSON(lockFile, stewfileLockFilePath)
+	stew.CatchAndExit(err)
+}
+
+// LockMigrate is executed when you run `stew lock migrate`
+func LockMigrate(lockFilePath string) {
+	if lockFilePath == "" {
+		_, _, _, systemInfo, err := stew.Initialize()
+		stew.CatchAndExit(err)
+		lockFilePath = systemInfo.StewLockFilePath
+	}
+
+	lockFile, err := stew.ReadStewLockFile(lockFilePath)
+	stew.CatchAndExit(err)
+
+	err = stew.WriteLockFileJSON(lockFile, lockFilePath)
+	stew.CatchAndExit(err)
+
+	fmt.Printf(
+		"✨ Migrated %v to lockfile schema version %v\n",
+		constants.GreenColor(lockFilePath),
+		constants.GreenColor(stew.LockFileSchemaVersion),
+	)
+}
@@ -2,8 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 
 	"github.com/marwanhawari/stew/constants"
 	stew "github.com/marwanhawari/stew/lib"
@@ -18,7 +16,6 @@ func Rename(cliInput string) {
 	err = stew.ValidateCLIInput(cliInput)
 	stew.CatchAndExit(err)
 
-	stewBinPath := systemInfo.StewBinPath
 	stewLockFilePath := systemInfo.StewLockFilePath
 
 	lockFile, err := stew.NewLockFile(stewLockFilePath, userOS, userArch)
@@ -34,10 +31,13 @@ func Rename(cliInput string) {
 		if pkg.Binary == cliInput {
 			renamedBinaryName, err = stew.PromptRenameBinary(cliInput)
 			stew.CatchAndExit(err)
-			err = os.Rename(filepath.Join(stewBinPath, cliInput), filepath.Join(stewBinPath, renamedBinaryName))
+			err = stew.RenameInstalledVersions(systemInfo, pkg, renamedBinaryName)
 			stew.CatchAndExit(err)
 
 			lockFile.Packages[index].Binary = renamedBinaryName
+			entry := stew.NewHistoryEntry("rename", cliInput, nil)
+			entry.NewBinary = renamedBinaryName
+			recordHistory(systemInfo, entry)
 			binaryFound = true
 			break
 		}
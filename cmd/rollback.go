@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/marwanhawari/stew/constants"
+	stew "github.com/marwanhawari/stew/lib"
+)
+
+// Rollback is executed when you run `stew rollback`
+func Rollback(rollbackAllCliFlag bool, binaryName string) {
+	userOS, userArch, _, systemInfo, err := stew.Initialize()
+	stew.CatchAndExit(err)
+
+	if rollbackAllCliFlag && binaryName != "" {
+		stew.CatchAndExit(stew.CLIFlagAndInputError{})
+	} else if !rollbackAllCliFlag {
+		err := stew.ValidateCLIInput(binaryName)
+		stew.CatchAndExit(err)
+	}
+
+	stewLockFilePath := systemInfo.StewLockFilePath
+
+	lockFile, err := stew.NewLockFile(stewLockFilePath, userOS, userArch)
+	stew.CatchAndExit(err)
+
+	history, err := stew.ReadRollbackHistory(systemInfo.StewPath)
+	stew.CatchAndExit(err)
+
+	var records []stew.UpgradeRecord
+	if rollbackAllCliFlag {
+		var found bool
+		history, records, found = stew.PopUpgradeAllRun(history)
+		if !found {
+			stew.CatchAndExit(stew.NoUpgradeToRollbackError{})
+		}
+	} else {
+		var record stew.UpgradeRecord
+		var found bool
+		history, record, found = stew.PopUpgradeRecord(history, binaryName)
+		if !found {
+			stew.CatchAndExit(stew.NoUpgradeToRollbackError{Binary: binaryName})
+		}
+		records = []stew.UpgradeRecord{record}
+	}
+
+	// Make sure every previous version is still installed before switching any of them
+	for _, record := range records {
+		if _, found := stew.FindBinaryInLockFile(lockFile, record.Binary); !found {
+			stew.CatchAndExit(stew.BinaryNotInstalledError{Binary: record.Binary})
+		}
+		_, err := stew.ReadInstalledVersion(systemInfo.StewPkgPath, record.Binary, record.PreviousVersion)
+		stew.CatchAndExit(err)
+	}
+
+	for _, record := range records {
+		indexInLockFile, _ := stew.FindBinaryInLockFile(lockFile, record.Binary)
+		previousPkg := lockFile.Packages[indexInLockFile]
+		err = stew.UseVersion(systemInfo, &lockFile, record.Binary, record.PreviousVersion)
+		stew.CatchAndExit(err)
+		recordHistory(systemInfo, newPackageHistoryEntry(systemInfo, "rollback", previousPkg, lockFile.Packages[indexInLockFile]))
+	}
+
+	err = stew.WriteLockFileJSON(lockFile, stewLockFilePath)
+	stew.CatchAndExit(err)
+	err = stew.WriteRollbackHistory(systemInfo.StewPath, history)
+	stew.CatchAndExit(err)
+
+	for _, record := range records {
+		fmt.Printf(
+			"⏪ Rolled back the %v binary from %v to %v\n",
+			constants.GreenColor(record.Binary),
+			constants.GreenColor(record.Version),
+			constants.GreenColor(record.PreviousVersion),
+		)
+	}
+}
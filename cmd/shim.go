@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/marwanhawari/stew/constants"
+	stew "github.com/marwanhawari/stew/lib"
+)
+
+// Shim is executed when you run `stew shim`
+func Shim(removeCliFlag bool, binaryName string) {
+	userOS, userArch, _, systemInfo, err := stew.Initialize()
+	stew.CatchAndExit(err)
+
+	err = stew.ValidateCLIInput(binaryName)
+	stew.CatchAndExit(err)
+
+	lockFile, err := stew.NewLockFile(systemInfo.StewLockFilePath, userOS, userArch)
+	stew.CatchAndExit(err)
+
+	indexInLockFile, binaryFoundInLockFile := stew.FindBinaryInLockFile(lockFile, binaryName)
+	if !binaryFoundInLockFile {
+		stew.CatchAndExit(stew.BinaryNotInstalledError{Binary: binaryName})
+	}
+
+	if removeCliFlag {
+		err = stew.RemoveShim(systemInfo, lockFile.Packages[indexInLockFile])
+		stew.CatchAndExit(err)
+		fmt.Printf("✨ Removed the shim for the %v binary\n", constants.GreenColor(binaryName))
+		return
+	}
+
+	err = stew.CreateShim(systemInfo.StewBinPath, binaryName)
+	stew.CatchAndExit(err)
+	fmt.Printf(
+		"✨ Created a shim for the %v binary. It runs the version pinned by the nearest %v or Stewfile.\n",
+		constants.GreenColor(binaryName),
+		constants.GreenColor(stew.StewVersionFileName),
+	)
+}
+
+// ShimInvocation returns the name of the binary to run if stew was invoked through a shim. The path it was
+// invoked as has to be a link to the stew executable in the installation path of the global or project environment.
+func ShimInvocation(invokedAs string) (string, bool) {
+	executablePath, err := os.Executable()
+	if err != nil {
+		return "", false
+	}
+	// Most invocations are of stew itself, which don't need the config to be read
+	if strings.TrimSuffix(filepath.Base(invokedAs), ".exe") == strings.TrimSuffix(filepath.Base(executablePath), ".exe") {
+		return "", false
+	}
+
+	invokedPath := invokedAs
+	if !strings.ContainsAny(invokedAs, `/\`) {
+		invokedPath, err = exec.LookPath(invokedAs)
+		if err != nil {
+			return "", false
+		}
+	}
+	invokedPath, err = filepath.Abs(invokedPath)
+	if err != nil {
+		return "", false
+	}
+
+	_, _, stewConfig, systemInfo, err := stew.InitializeQuiet()
+	if err != nil {
+		return "", false
+	}
+	stewBinPaths := []string{systemInfo.StewBinPath, stewConfig.StewBinPath}
+	shimName := stew.ShimName(invokedPath, executablePath, stewBinPaths)
+	return shimName, shimName != ""
+}
+
+// RunShim is executed when stew is invoked through a shim. It runs the version of the binary that is pinned for
+// the working directory and exits with its exit code.
+func RunShim(binaryName string, args []string) {
+	userOS, userArch, stewConfig, systemInfo, err := stew.InitializeQuiet()
+	stew.CatchAndExit(err)
+
+	systemInfos := []stew.SystemInfo{systemInfo}
+	if systemInfo.ProjectPath != "" {
+		systemInfos = append(systemInfos, stew.NewSystemInfo(stewConfig))
+	}
+	lockFiles := []stew.LockFile{}
+	for _, info := range systemInfos {
+		lockFile, err := stew.NewLockFile(info.StewLockFilePath, userOS, userArch)
+		stew.CatchAndExit(err)
+		lockFiles = append(lockFiles, lockFile)
+	}
+
+	workingDir, err := os.Getwd()
+	stew.CatchAndExit(err)
+	binaryPath, err := stew.ResolveShim(systemInfos, lockFiles, workingDir, binaryName)
+	stew.CatchAndExit(err)
+
+	command := exec.Command(binaryPath, args...)
+	command.Args[0] = binaryName
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	err = command.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.ExitCode())
+	}
+	stew.CatchAndExit(err)
+}
+
+// pinLocalVersion pins a version of a binary in the .stew-version file of the working directory
+func pinLocalVersion(systemInfo stew.SystemInfo, binaryName, version string) {
+	_, err := stew.ReadInstalledVersion(systemInfo.StewPkgPath, binaryName, version)
+	stew.CatchAndExit(err)
+
+	workingDir, err := os.Getwd()
+	stew.CatchAndExit(err)
+	err = stew.WriteStewVersionFile(workingDir, binaryName, version)
+	stew.CatchAndExit(err)
+
+	fmt.Printf(
+		"📌 Pinned the %v binary to %v in %v\n",
+		constants.GreenColor(binaryName),
+		constants.GreenColor(version),
+		constants.GreenColor(filepath.Join(workingDir, stew.StewVersionFileName)),
+	)
+	if !stew.IsShim(filepath.Join(systemInfo.StewBinPath, binaryName)) {
+		fmt.Printf("Run %v so that the pinned version is used in this directory\n", constants.GreenColor("stew shim "+binaryName))
+	}
+}
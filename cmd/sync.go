@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/marwanhawari/stew/constants"
+	stew "github.com/marwanhawari/stew/lib"
+)
+
+// Sync is executed when you run `stew sync`
+func Sync(stewfilePath string, groups []string, prune, dryRun bool) {
+	userOS, userArch, _, systemInfo, err := stew.Initialize()
+	stew.CatchAndExit(err)
+
+	err = stew.ValidateCLIInput(stewfilePath)
+	stew.CatchAndExit(err)
+
+	stewfilePackages, err := stew.ReadStewfileContents(stewfilePath)
+	stew.CatchAndExit(err)
+
+	lockFile, err := stew.NewLockFile(systemInfo.StewLockFilePath, userOS, userArch)
+	stew.CatchAndExit(err)
+
+	// Without any groups, the selection from the last install or sync is used
+	if len(groups) == 0 {
+		groups = lockFile.SelectedGroups
+	}
+	packages, err := stew.FilterStewfileGroups(stewfilePackages, groups)
+	stew.CatchAndExit(err)
+	platformPackages := []stew.PackageData{}
+	for _, pkg := range packages {
+		if stew.MatchesPlatform(pkg, userOS, userArch) {
+			platformPackages = append(platformPackages, pkg)
+		}
+	}
+	packages = platformPackages
+
+	// Only the binaries that are not in the Stewfile at all are pruned
+	plan := stew.NewSyncPlan(packages, stewfilePackages, lockFile, prune)
+	if len(plan) == 0 {
+		if !dryRun {
+			err = recordSelectedGroups(systemInfo.StewLockFilePath, userOS, userArch, groups)
+			stew.CatchAndExit(err)
+		}
+		fmt.Printf("✨ The installed binaries already match %v\n", constants.GreenColor(stewfilePath))
+		return
+	}
+
+	fmt.Printf("📋 Syncing with %v:\n", constants.GreenColor(stewfilePath))
+	for _, step := range plan {
+		fmt.Println(stew.FormatSyncStep(step))
+	}
+	if dryRun {
+		return
+	}
+
+	for _, step := range plan {
+		switch step.Action {
+		case stew.SyncInstall:
+			installStewfilePackage(step.Package, InstallOptions{})
+		case stew.SyncUpgrade, stew.SyncDowngrade, stew.SyncReinstall:
+			installStewfilePackage(step.Package, InstallOptions{Overwrite: true})
+		case stew.SyncRemove:
+			Uninstall(false, step.Installed.Binary)
+		}
+	}
+
+	err = recordSelectedGroups(systemInfo.StewLockFilePath, userOS, userArch, groups)
+	stew.CatchAndExit(err)
+}
@@ -21,7 +21,7 @@ func Uninstall(cliFlag bool, binaryName string) {
 	}
 
 	stewBinPath := systemInfo.StewBinPath
-	stewPkgPath := systemInfo.StewPkgPath
+	stewAssetPath := systemInfo.StewAssetPath
 	stewLockFilePath := systemInfo.StewLockFilePath
 
 	lockFile, err := stew.NewLockFile(stewLockFilePath, userOS, userArch)
@@ -33,16 +33,26 @@ func Uninstall(cliFlag bool, binaryName string) {
 
 	if cliFlag {
 		for _, pkg := range lockFile.Packages {
-			err = stew.DeleteAssetAndBinary(stewPkgPath, stewBinPath, pkg.Asset, pkg.Binary)
+			err = stew.DeleteAssetAndBinary(stewAssetPath, stewBinPath, pkg.Asset, pkg.Binary)
 			stew.CatchAndExit(err)
+			err = stew.DeleteExtraFiles(stewBinPath, pkg.InstalledExtraFiles)
+			stew.CatchAndExit(err)
+			err = stew.DeleteInstalledVersions(systemInfo, pkg.Binary)
+			stew.CatchAndExit(err)
+			recordHistory(systemInfo, newPackageHistoryEntry(systemInfo, "uninstall", pkg, stew.PackageData{Binary: pkg.Binary, Asset: pkg.Asset}))
 		}
 		lockFile.Packages = []stew.PackageData{}
 	} else {
 		var binaryFound bool
 		for index, pkg := range lockFile.Packages {
 			if pkg.Binary == binaryName {
-				err = stew.DeleteAssetAndBinary(stewPkgPath, stewBinPath, pkg.Asset, pkg.Binary)
+				err = stew.DeleteAssetAndBinary(stewAssetPath, stewBinPath, pkg.Asset, pkg.Binary)
+				stew.CatchAndExit(err)
+				err = stew.DeleteExtraFiles(stewBinPath, pkg.InstalledExtraFiles)
+				stew.CatchAndExit(err)
+				err = stew.DeleteInstalledVersions(systemInfo, pkg.Binary)
 				stew.CatchAndExit(err)
+				recordHistory(systemInfo, newPackageHistoryEntry(systemInfo, "uninstall", pkg, stew.PackageData{Binary: pkg.Binary, Asset: pkg.Asset}))
 				lockFile.Packages, err = stew.RemovePackage(lockFile.Packages, index)
 				stew.CatchAndExit(err)
 				binaryFound = true
@@ -4,14 +4,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/marwanhawari/stew/constants"
 	stew "github.com/marwanhawari/stew/lib"
 )
 
 // Upgrade is executed when you run `stew upgrade`
-func Upgrade(upgradeAllCliFlag bool, binaryName string) {
+func Upgrade(upgradeAllCliFlag, noKeepAssetCliFlag, changelogCliFlag bool, binaryName string) {
 	userOS, userArch, _, systemInfo, err := stew.Initialize()
 	stew.CatchAndExit(err)
 
@@ -37,17 +36,20 @@ func Upgrade(upgradeAllCliFlag bool, binaryName string) {
 		stew.CatchAndExit(stew.NoBinariesInstalledError{})
 	}
 
+	run := upgradeRun{id: stew.NewUpgradeRun(), all: upgradeAllCliFlag, noKeepAsset: noKeepAssetCliFlag, changelog: changelogCliFlag}
 	if upgradeAllCliFlag {
-		upgradeAll(userOS, userArch, lockFile, systemInfo)
+		upgradeAll(userOS, userArch, lockFile, systemInfo, run)
 	} else {
-		err := upgradeOne(binaryName, userOS, userArch, lockFile, systemInfo)
+		err := upgradeOne(binaryName, userOS, userArch, lockFile, systemInfo, run)
+		recordUpgradeFailure(systemInfo, binaryName, err)
 		stew.CatchAndExit(err)
 	}
 }
 
-func upgradeOne(binaryName, userOS, userArch string, lockFile stew.LockFile, systemInfo stew.SystemInfo) error {
+func upgradeOne(binaryName, userOS, userArch string, lockFile stew.LockFile, systemInfo stew.SystemInfo, run upgradeRun) error {
 	sp := constants.LoadingSpinner
 	stewPkgPath := systemInfo.StewPkgPath
+	stewAssetPath := systemInfo.StewAssetPath
 	stewLockFilePath := systemInfo.StewLockFilePath
 
 	indexInLockFile, binaryFoundInLockFile := stew.FindBinaryInLockFile(lockFile, binaryName)
@@ -60,206 +62,163 @@ func upgradeOne(binaryName, userOS, userArch string, lockFile stew.LockFile, sys
 	if pkg.Source == "other" {
 		return stew.InstalledFromURLError{Binary: pkg.Binary}
 	}
-	owner := pkg.Owner
-	repo := pkg.Repo
 
-	switch pkg.Source {
-	case "github":
-		sp.Start()
-		githubProject, err := stew.NewGithubProject(owner, repo)
-		sp.Stop()
-		if err != nil {
-			return err
-		}
-
-		// This will make sure that there are any tags at all
-		_, err = stew.GetGithubReleasesTags(githubProject)
-		if err != nil {
-			return err
-		}
-
-		// Get the latest tag
-		tagIndex := 0
-		tag := githubProject.Releases[tagIndex].TagName
-
-		if pkg.Tag == tag {
-			return stew.AlreadyInstalledLatestTagError{Tag: tag}
-		}
-
-		// Make sure there are any assets at all
-		releaseAssets, err := stew.GetGithubReleasesAssets(githubProject, tag)
-		if err != nil {
-			return err
-		}
-
-		asset, err := stew.DetectAsset(userOS, userArch, releaseAssets)
-		if err != nil {
-			return err
-		}
-		assetIndex, _ := stew.Contains(releaseAssets, asset)
-		downloadURL := githubProject.Releases[tagIndex].Assets[assetIndex].DownloadURL
-		downloadPath := filepath.Join(stewPkgPath, asset)
-		err = stew.DownloadFile(downloadPath, downloadURL, pkg.Source)
-		if err != nil {
-			return err
-		}
-		fmt.Printf("✅ Downloaded %v to %v\n", constants.GreenColor(asset), constants.GreenColor(stewPkgPath))
-
-		_, err = stew.InstallBinary(downloadPath, repo, systemInfo, &lockFile, true)
-		if err != nil {
-			if err := os.RemoveAll(downloadPath); err != nil {
-				return err
-			}
-		}
-
-		lockFile.Packages[indexInLockFile].Tag = tag
-		lockFile.Packages[indexInLockFile].Asset = asset
-		lockFile.Packages[indexInLockFile].URL = downloadURL
-		if err := stew.WriteLockFileJSON(lockFile, stewLockFilePath); err != nil {
-			return err
-		}
-
-		fmt.Printf(
-			"✨ Successfully upgraded the %v binary from %v to %v\n",
-			constants.GreenColor(pkg.Binary),
-			constants.GreenColor(pkg.Tag),
-			constants.GreenColor(tag),
-		)
-		return nil
-	case "gitlab":
-		sp.Start()
-		gitlabProject, err := stew.NewGitlabProject(pkg.Host, strings.Split(owner, "/"), repo)
-		sp.Stop()
-		if err != nil {
-			return err
-		}
-
-		// This will make sure that there are any tags at all
-		_, err = stew.GetGitlabReleasesTags(gitlabProject, pkg.Host)
-		if err != nil {
-			return err
-		}
-
-		// Get the latest tag
-		tagIndex := 0
-		tag := gitlabProject.Releases[tagIndex].TagName
-
-		if pkg.Tag == tag {
-			return stew.AlreadyInstalledLatestTagError{Tag: tag}
-		}
+	// Binaries installed before versions were kept side by side are moved into their version directory first,
+	// so that the upgrade can be rolled back
+	if err := stew.RetainInstalledVersion(systemInfo, pkg); err != nil {
+		return err
+	}
 
-		// Make sure there are any assets at all
-		releaseAssets, err := stew.GetGitlabReleasesAssets(gitlabProject, tag)
-		if err != nil {
-			return err
-		}
+	// The installed tag and asset are selected again, but any Stewfile options the package was installed with still apply
+	upgradeSpec := pkg
+	upgradeSpec.Tag = ""
+	upgradeSpec.Asset = ""
 
-		asset, err := stew.DetectAsset(userOS, userArch, releaseAssets)
-		if err != nil {
-			return err
-		}
-		assetIndex, _ := stew.Contains(releaseAssets, asset)
-		downloadURL := gitlabProject.Releases[tagIndex].Assets.Links[assetIndex].DownloadURL
-		downloadPath := filepath.Join(stewPkgPath, asset)
-		err = stew.DownloadFile(downloadPath, downloadURL, pkg.Source)
-		if err != nil {
-			return err
-		}
-		fmt.Printf("✅ Downloaded %v to %v\n", constants.GreenColor(asset), constants.GreenColor(stewPkgPath))
+	sp.Start()
+	releases, err := stew.GetReleases(pkg)
+	sp.Stop()
+	if err != nil {
+		return err
+	}
+	releaseTags := []string{}
+	for _, release := range releases {
+		releaseTags = append(releaseTags, release.TagName)
+	}
 
-		_, err = stew.InstallBinary(downloadPath, repo, systemInfo, &lockFile, true)
-		if err != nil {
-			if err := os.RemoveAll(downloadPath); err != nil {
-				return err
-			}
-		}
+	// Get the latest tag that satisfies the constraint and channel the package was installed with
+	tag, err := stew.SelectTag(releaseTags, upgradeSpec)
+	if err != nil {
+		return err
+	}
+	if pkg.Tag == tag {
+		return stew.AlreadyInstalledLatestTagError{Tag: tag}
+	}
+	if run.changelog {
+		printChangelog(pkg, releases, tag)
+	}
 
-		lockFile.Packages[indexInLockFile].Tag = tag
-		lockFile.Packages[indexInLockFile].Asset = asset
-		lockFile.Packages[indexInLockFile].URL = downloadURL
-		if err := stew.WriteLockFileJSON(lockFile, stewLockFilePath); err != nil {
-			return err
-		}
+	release, err := stew.FindRelease(releases, tag)
+	if err != nil {
+		return err
+	}
+	// Make sure there are any assets at all
+	releaseAssets := stew.GetReleaseAssetNames(release)
+	if len(releaseAssets) == 0 {
+		return stew.AssetsNotFoundError{Tag: tag}
+	}
 
-		fmt.Printf(
-			"✨ Successfully upgraded the %v binary from %v to %v\n",
-			constants.GreenColor(pkg.Binary),
-			constants.GreenColor(pkg.Tag),
-			constants.GreenColor(tag),
-		)
-		return nil
-	case "gitea":
-		sp.Start()
-		giteaProject, err := stew.NewGiteaProject(pkg.Host, owner, repo)
-		sp.Stop()
-		if err != nil {
-			return err
-		}
+	asset, err := stew.SelectAsset(releaseAssets, upgradeSpec, userOS, userArch)
+	if err != nil {
+		return err
+	}
+	releaseAsset, err := stew.FindReleaseAsset(release, asset)
+	if err != nil {
+		return err
+	}
+	downloadURL := releaseAsset.DownloadURL
+	downloadPath := filepath.Join(stewAssetPath, asset)
+	err = stew.DownloadFile(downloadPath, downloadURL, pkg.Source)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Downloaded %v to %v\n", constants.GreenColor(asset), constants.GreenColor(stewAssetPath))
 
-		// This will make sure that there are any tags at all
-		_, err = stew.GetGiteaReleasesTags(giteaProject)
-		if err != nil {
+	// The binary keeps the name it was installed under, which may have been set with the binary option of a Stewfile
+	installSpec := upgradeSpec
+	installSpec.Tag = tag
+	_, extraFiles, err := stew.InstallPackageBinary(downloadPath, pkg.Repo, installSpec, systemInfo, &lockFile, true)
+	if err != nil {
+		if err := os.RemoveAll(downloadPath); err != nil {
 			return err
 		}
+		return err
+	}
 
-		// Get the latest tag
-		tagIndex := 0
-		tag := giteaProject.Releases[tagIndex].TagName
-
-		if pkg.Tag == tag {
-			return stew.AlreadyInstalledLatestTagError{Tag: tag}
-		}
-
-		// Make sure there are any assets at all
-		releaseAssets, err := stew.GetGiteaReleasesAssets(giteaProject, tag)
-		if err != nil {
+	lockFile.Packages[indexInLockFile].Tag = tag
+	lockFile.Packages[indexInLockFile].Asset = asset
+	lockFile.Packages[indexInLockFile].URL = downloadURL
+	lockFile.Packages[indexInLockFile].InstalledExtraFiles = extraFiles
+	lockFile.Packages[indexInLockFile].BinarySHA256, err = stew.InstalledBinarySHA256(stewPkgPath, lockFile.Packages[indexInLockFile])
+	if err != nil {
+		return err
+	}
+	lockFile.Packages[indexInLockFile].ExtraFilesSHA256, err = stew.InstalledExtraFilesSHA256(stewPkgPath, lockFile.Packages[indexInLockFile])
+	if err != nil {
+		return err
+	}
+	if err := stew.WriteLockFileJSON(lockFile, stewLockFilePath); err != nil {
+		return err
+	}
+	if err := stew.RecordInstalledVersion(stewPkgPath, lockFile.Packages[indexInLockFile]); err != nil {
+		return err
+	}
+	if err := recordUpgrade(systemInfo, run, pkg, lockFile.Packages[indexInLockFile]); err != nil {
+		return err
+	}
+	if run.noKeepAsset {
+		if err := stew.DeleteCachedAsset(stewAssetPath, asset); err != nil {
 			return err
 		}
+	}
 
-		asset, err := stew.DetectAsset(userOS, userArch, releaseAssets)
-		if err != nil {
-			return err
-		}
-		assetIndex, _ := stew.Contains(releaseAssets, asset)
-		downloadURL := giteaProject.Releases[tagIndex].Assets[assetIndex].DownloadURL
-		downloadPath := filepath.Join(stewPkgPath, asset)
-		err = stew.DownloadFile(downloadPath, downloadURL, pkg.Source)
-		if err != nil {
-			return err
-		}
-		fmt.Printf("✅ Downloaded %v to %v\n", constants.GreenColor(asset), constants.GreenColor(stewPkgPath))
+	fmt.Printf(
+		"✨ Successfully upgraded the %v binary from %v to %v\n",
+		constants.GreenColor(pkg.Binary),
+		constants.GreenColor(pkg.Tag),
+		constants.GreenColor(tag),
+	)
+	return nil
+}
 
-		_, err = stew.InstallBinary(downloadPath, repo, systemInfo, &lockFile, true)
-		if err != nil {
-			if err := os.RemoveAll(downloadPath); err != nil {
-				return err
-			}
+func upgradeAll(userOS, userArch string, lockFile stew.LockFile, systemInfo stew.SystemInfo, run upgradeRun) {
+	for _, pkg := range lockFile.Packages {
+		// Packages from Stewfile groups that are no longer selected are left as they are
+		if !stew.InSelectedGroups(pkg, lockFile.SelectedGroups) {
+			continue
 		}
-
-		lockFile.Packages[indexInLockFile].Tag = tag
-		lockFile.Packages[indexInLockFile].Asset = asset
-		lockFile.Packages[indexInLockFile].URL = downloadURL
-		if err := stew.WriteLockFileJSON(lockFile, stewLockFilePath); err != nil {
-			return err
+		if err := upgradeOne(pkg.Binary, userOS, userArch, lockFile, systemInfo, run); err != nil {
+			recordUpgradeFailure(systemInfo, pkg.Binary, err)
+			fmt.Fprintln(os.Stderr, err)
+			continue
 		}
+	}
+}
 
-		fmt.Printf(
-			"✨ Successfully upgraded the %v binary from %v to %v\n",
-			constants.GreenColor(pkg.Binary),
-			constants.GreenColor(pkg.Tag),
-			constants.GreenColor(tag),
-		)
-		return nil
+// upgradeRun identifies the upgrades done by one run of stew upgrade, so that stew rollback --all can undo them together.
+// It also holds the options that apply to every upgrade of the run.
+type upgradeRun struct {
+	id          string
+	all         bool
+	noKeepAsset bool
+	changelog   bool
+}
 
+// recordUpgrade saves an upgrade in the rollback history and the history journal
+func recordUpgrade(systemInfo stew.SystemInfo, run upgradeRun, previousPkg, pkg stew.PackageData) error {
+	history, err := stew.ReadRollbackHistory(systemInfo.StewPath)
+	if err != nil {
+		return err
 	}
+	history = stew.AddUpgradeRecord(history, stew.UpgradeRecord{
+		Binary:          pkg.Binary,
+		PreviousVersion: stew.InstalledVersionName(previousPkg),
+		Version:         stew.InstalledVersionName(pkg),
+		Run:             run.id,
+		All:             run.all,
+	})
+	if err := stew.WriteRollbackHistory(systemInfo.StewPath, history); err != nil {
+		return err
+	}
+	recordHistory(systemInfo, newPackageHistoryEntry(systemInfo, "upgrade", previousPkg, pkg))
 	return nil
 }
 
-func upgradeAll(userOS, userArch string, lockFile stew.LockFile, systemInfo stew.SystemInfo) {
-	for _, pkg := range lockFile.Packages {
-		if err := upgradeOne(pkg.Binary, userOS, userArch, lockFile, systemInfo); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			continue
-		}
+// recordUpgradeFailure saves a failed upgrade in the history journal. Binaries that are already up to date or that
+// were installed from a URL weren't upgraded at all, so nothing is recorded for them.
+func recordUpgradeFailure(systemInfo stew.SystemInfo, binaryName string, err error) {
+	switch err.(type) {
+	case nil, stew.AlreadyInstalledLatestTagError, stew.InstalledFromURLError, stew.BinaryNotInstalledError:
+		return
 	}
+	recordHistory(systemInfo, stew.NewHistoryEntry("upgrade", binaryName, err))
 }
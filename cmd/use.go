@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/marwanhawari/stew/constants"
+	stew "github.com/marwanhawari/stew/lib"
+)
+
+// Use is executed when you run `stew use`
+func Use(localCliFlag bool, cliInput string) {
+	userOS, userArch, _, systemInfo, err := stew.Initialize()
+	stew.CatchAndExit(err)
+
+	err = stew.ValidateCLIInput(cliInput)
+	stew.CatchAndExit(err)
+
+	stewLockFilePath := systemInfo.StewLockFilePath
+
+	lockFile, err := stew.NewLockFile(stewLockFilePath, userOS, userArch)
+	stew.CatchAndExit(err)
+
+	binaryName, version, _ := strings.Cut(cliInput, "@")
+	indexInLockFile, binaryFoundInLockFile := stew.FindBinaryInLockFile(lockFile, binaryName)
+	if !binaryFoundInLockFile {
+		stew.CatchAndExit(stew.BinaryNotInstalledError{Binary: binaryName})
+	}
+	previousPkg := lockFile.Packages[indexInLockFile]
+	previousVersion := stew.InstalledVersionName(previousPkg)
+
+	if version == "" {
+		versions, err := stew.ListInstalledVersions(systemInfo.StewPkgPath, binaryName)
+		stew.CatchAndExit(err)
+		if len(versions) == 0 {
+			stew.CatchAndExit(stew.VersionNotInstalledError{Binary: binaryName, Version: previousVersion})
+		}
+		version, err = stew.PromptSelect("Choose a version:", versions)
+		stew.CatchAndExit(err)
+	}
+
+	if localCliFlag {
+		pinLocalVersion(systemInfo, binaryName, version)
+		return
+	}
+
+	err = stew.UseVersion(systemInfo, &lockFile, binaryName, version)
+	stew.CatchAndExit(err)
+	recordHistory(systemInfo, newPackageHistoryEntry(systemInfo, "use", previousPkg, lockFile.Packages[indexInLockFile]))
+
+	err = stew.WriteLockFileJSON(lockFile, stewLockFilePath)
+	stew.CatchAndExit(err)
+
+	fmt.Printf(
+		"✨ Switched the %v binary from %v to %v\n",
+		constants.GreenColor(binaryName),
+		constants.GreenColor(previousVersion),
+		constants.GreenColor(version),
+	)
+}
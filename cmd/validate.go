@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/marwanhawari/stew/constants"
+	stew "github.com/marwanhawari/stew/lib"
+)
+
+// Validate is executed when you run `stew validate`
+func Validate(stewfilePath string) {
+	err := stew.ValidateCLIInput(stewfilePath)
+	stew.CatchAndExit(err)
+
+	packages, errs, err := stew.ValidateStewfile(stewfilePath)
+	stew.CatchAndExit(err)
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
+
+	fmt.Printf(
+		"✨ %v is valid and contains %v packages\n",
+		constants.GreenColor(stewfilePath),
+		constants.GreenColor(len(packages)),
+	)
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/marwanhawari/stew/constants"
+	stew "github.com/marwanhawari/stew/lib"
+)
+
+// Verify is executed when you run `stew verify`
+func Verify(repairCliFlag bool, binaryName string) {
+	userOS, userArch, _, systemInfo, err := stew.Initialize()
+	stew.CatchAndExit(err)
+
+	lockFile, err := stew.NewLockFile(systemInfo.StewLockFilePath, userOS, userArch)
+	stew.CatchAndExit(err)
+
+	packages := []stew.PackageData{}
+	if binaryName != "" {
+		indexInLockFile, binaryFoundInLockFile := stew.FindBinaryInLockFile(lockFile, binaryName)
+		if !binaryFoundInLockFile {
+			stew.CatchAndExit(stew.BinaryNotInstalledError{Binary: binaryName})
+		}
+		packages = append(packages, lockFile.Packages[indexInLockFile])
+	} else {
+		for _, pkg := range lockFile.Packages {
+			// Entries that were only locked for other platforms were never installed
+			if pkg.Binary != "" {
+				packages = append(packages, pkg)
+			}
+		}
+	}
+	if len(packages) == 0 {
+		stew.CatchAndExit(stew.NoBinariesInstalledError{})
+	}
+
+	problems := 0
+	for _, pkg := range packages {
+		verifications, err := stew.VerifyBinary(systemInfo, pkg)
+		stew.CatchAndExit(err)
+		if repairCliFlag && !binaryVerified(verifications) {
+			printVerifications(verifications)
+			err := stew.RepairBinary(systemInfo, pkg)
+			recordHistory(systemInfo, repairHistoryEntry(systemInfo, pkg, err))
+			if err != nil {
+				fmt.Println(err)
+				problems++
+				continue
+			}
+			verifications, err = stew.VerifyBinary(systemInfo, pkg)
+			stew.CatchAndExit(err)
+			if !binaryVerified(verifications) {
+				printVerifications(verifications)
+				problems++
+				continue
+			}
+			fmt.Printf("✅ Repaired the %v binary\n", constants.GreenColor(pkg.Binary))
+			continue
+		}
+		printVerifications(verifications)
+		if !binaryVerified(verifications) {
+			problems++
+		}
+	}
+
+	if problems == 0 {
+		fmt.Printf("✨ No modified or missing files in %v\n", constants.GreenColor(systemInfo.StewBinPath))
+		return
+	}
+	if !repairCliFlag {
+		fmt.Println("💡 Run stew verify --repair to reinstall the binaries that failed verification")
+	}
+	os.Exit(1)
+}
+
+// binaryVerified checks if none of the files of a binary are modified or missing
+func binaryVerified(verifications []stew.FileVerification) bool {
+	for _, verification := range verifications {
+		if verification.Status == stew.VerifyModified || verification.Status == stew.VerifyMissing {
+			return false
+		}
+	}
+	return true
+}
+
+func printVerifications(verifications []stew.FileVerification) {
+	for _, verification := range verifications {
+		switch verification.Status {
+		case stew.VerifyOK:
+			fmt.Printf("✅ %v\n", verification.Path)
+		case stew.VerifyModified:
+			fmt.Printf(
+				"❌ %v was modified: its SHA256 digest is %v but %v was recorded\n",
+				constants.RedColor(verification.Path),
+				constants.RedColor(verification.Actual),
+				constants.RedColor(verification.Expected),
+			)
+		case stew.VerifyMissing:
+			fmt.Printf("❌ %v is missing\n", constants.RedColor(verification.Path))
+		case stew.VerifyUnrecorded:
+			fmt.Printf(
+				"⚠️  %v has no recorded SHA256 digest, so it can't be verified. Upgrade or reinstall the %v binary to record one.\n",
+				constants.YellowColor(verification.Path),
+				constants.YellowColor(verification.Binary),
+			)
+		}
+	}
+}
+
+func repairHistoryEntry(systemInfo stew.SystemInfo, pkg stew.PackageData, err error) stew.HistoryEntry {
+	if err != nil {
+		entry := stew.NewHistoryEntry("repair", pkg.Binary, err)
+		entry.OldTag, entry.NewTag, entry.Asset = pkg.Tag, pkg.Tag, pkg.Asset
+		return entry
+	}
+	return newPackageHistoryEntry(systemInfo, "repair", pkg, pkg)
+}
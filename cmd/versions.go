@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/marwanhawari/stew/constants"
+	stew "github.com/marwanhawari/stew/lib"
+)
+
+// Versions is executed when you run `stew versions`
+func Versions(binaryName string) {
+	userOS, userArch, _, systemInfo, err := stew.Initialize()
+	stew.CatchAndExit(err)
+
+	err = stew.ValidateCLIInput(binaryName)
+	stew.CatchAndExit(err)
+
+	lockFile, err := stew.NewLockFile(systemInfo.StewLockFilePath, userOS, userArch)
+	stew.CatchAndExit(err)
+
+	indexInLockFile, binaryFoundInLockFile := stew.FindBinaryInLockFile(lockFile, binaryName)
+	if !binaryFoundInLockFile {
+		stew.CatchAndExit(stew.BinaryNotInstalledError{Binary: binaryName})
+	}
+	activeVersion := stew.InstalledVersionName(lockFile.Packages[indexInLockFile])
+
+	versions, err := stew.ListInstalledVersions(systemInfo.StewPkgPath, binaryName)
+	stew.CatchAndExit(err)
+	// Binaries installed before versions were kept side by side only have their active version
+	if _, found := stew.Contains(versions, activeVersion); !found {
+		versions = append([]string{activeVersion}, versions...)
+	}
+
+	for _, version := range versions {
+		if version == activeVersion {
+			fmt.Printf("%v %v\n", constants.GreenColor(version), constants.GreenColor("(active)"))
+		} else {
+			fmt.Println(version)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/marwanhawari/stew/constants"
+	stew "github.com/marwanhawari/stew/lib"
+)
+
+// Which is executed when you run `stew which`
+func Which(cliInput string) {
+	userOS, userArch, _, systemInfo, err := stew.Initialize()
+	stew.CatchAndExit(err)
+
+	err = stew.ValidateCLIInput(cliInput)
+	stew.CatchAndExit(err)
+
+	lockFile, err := stew.NewLockFile(systemInfo.StewLockFilePath, userOS, userArch)
+	stew.CatchAndExit(err)
+
+	pathVariable := os.Getenv("PATH")
+	binaryPath, foundInPath := "", false
+	if !strings.ContainsAny(cliInput, `/\`) {
+		binaryPath, foundInPath = stew.LookPath(pathVariable, cliInput)
+	}
+	if !foundInPath {
+		binaryPath, err = stew.ResolveBinaryPath(cliInput, systemInfo.StewBinPath, "")
+		stew.CatchAndExit(err)
+	}
+	fmt.Println(constants.GreenColor(binaryPath))
+
+	pkg, owned := stew.FindOwningPackage(systemInfo, lockFile, binaryPath)
+	if !owned {
+		fmt.Printf("%v is not managed by stew\n", constants.YellowColor(binaryPath))
+		if indexInLockFile, managed := stew.FindBinaryInLockFile(lockFile, cliInput); managed {
+			fmt.Printf(
+				"⚠️  It shadows the %v binary that stew installed in %v\n",
+				constants.YellowColor(lockFile.Packages[indexInLockFile].Binary),
+				constants.YellowColor(systemInfo.StewBinPath),
+			)
+			return
+		}
+		fmt.Printf("💡 Run stew adopt %v to manage it with stew\n", binaryPath)
+		return
+	}
+
+	history := readHistory(systemInfo, pkg.Binary)
+	printPackageField("Binary", pkg.Binary)
+	printPackageField("Source", pkg.Source)
+	printPackageField("Host", stew.PackageHost(pkg))
+	if pkg.Source != "other" {
+		printPackageField("Repo", stew.GetPackageOwner(pkg)+"/"+pkg.Repo)
+		printPackageField("Tag", pkg.Tag)
+	}
+	printPackageField("Asset", pkg.Asset)
+	printPackageField("URL", pkg.URL)
+	if installTime, found := stew.InstallTime(systemInfo.StewPkgPath, history, pkg); found {
+		printPackageField("Installed", installTime.Local().Format("2006-01-02 15:04:05"))
+	}
+	if shadowingBinary, shadowed := stew.FindShadowingBinary(pathVariable, systemInfo.StewBinPath, pkg.Binary); shadowed {
+		printPackageField("Shadowed", constants.YellowColor("yes, by "+shadowingBinary))
+	} else {
+		printPackageField("Shadowed", "no")
+	}
+}
+
+// printPackageField prints one field of the details of an installed package
+func printPackageField(name, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Printf("  %-14v %v\n", name+":", value)
+}
@@ -7,6 +7,9 @@ import (
 	"github.com/gookit/color"
 )
 
+// StewVersion is the version of stew
+const StewVersion = "v0.3.0"
+
 // RedColor makes text red
 var RedColor = color.New(color.FgRed, color.OpBold).Render
 
@@ -45,8 +48,8 @@ var Regex386 = `(?i)(i?386|x86_32|amd32|x32)`
 // RegexGithub is a regular express for valid GitHub repos
 var RegexGithub = `(?i)^[A-Za-z0-9\-]+\/[A-Za-z0-9\_\.\-]+(@.+)?$`
 
-// RegexGithub is a regular express for valid GitHub repos
-var RegexGitlab = `(?i)^[A-Za-z0-9\-]+\/[A-Za-z0-9\_\.\-]+(@.+)?$`
+// RegexGitlab is a regular express for valid GitLab projects, which can be nested in any number of subgroups
+var RegexGitlab = `(?i)^[A-Za-z0-9\-]+(\/[A-Za-z0-9\_\.\-]+)+(@.+)?$`
 
 // RegexGithubSearch is a regular express for valid GitHub search queries
 var RegexGithubSearch = `(?i)^[A-Za-z0-9\_\.\-\/]+$`
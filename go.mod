@@ -3,12 +3,14 @@ module github.com/marwanhawari/stew
 go 1.22
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/briandowns/spinner v1.23.0
 	github.com/charmbracelet/huh v0.3.0
 	github.com/gookit/color v1.5.4
 	github.com/mholt/archiver v3.1.1+incompatible
 	github.com/schollz/progressbar/v3 v3.14.2
 	github.com/urfave/cli/v3 v3.0.0-alpha9
+	golang.org/x/text v0.14.0
 )
 
 require (
@@ -43,5 +45,4 @@ require (
 	golang.org/x/sync v0.4.0 // indirect
 	golang.org/x/sys v0.18.0 // indirect
 	golang.org/x/term v0.18.0 // indirect
-	golang.org/x/text v0.14.0 // indirect
 )
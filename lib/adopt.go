@@ -0,0 +1,161 @@
+package stew
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// versionCommandTimeout limits how long a binary can take to print its version when it is adopted
+const versionCommandTimeout = 5 * time.Second
+
+var versionRegex = regexp.MustCompile(`\d+(\.\d+)+(-[0-9A-Za-z.]+)?`)
+
+// ResolveBinaryPath finds a binary by its path, or by its name in the ~/.stew/bin path and then the PATH variable
+func ResolveBinaryPath(input, stewBinPath, pathVariable string) (string, error) {
+	if strings.ContainsRune(input, filepath.Separator) || strings.ContainsRune(input, '/') {
+		absolutePath, err := filepath.Abs(input)
+		if err != nil {
+			return "", err
+		}
+		if fileInfo, err := os.Stat(absolutePath); err != nil || fileInfo.IsDir() {
+			return "", BinaryNotFoundError{Binary: input}
+		}
+		return absolutePath, nil
+	}
+	for _, dir := range append([]string{stewBinPath}, filepath.SplitList(pathVariable)...) {
+		if dir == "" {
+			continue
+		}
+		candidate := filepath.Join(dir, input)
+		if fileInfo, err := os.Stat(candidate); err == nil && !fileInfo.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", BinaryNotFoundError{Binary: input}
+}
+
+// FindAdoptionCandidates returns the executables in a directory that are not managed by stew
+func FindAdoptionCandidates(dir string, lockFile LockFile) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	candidates := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		// stew itself is never adopted
+		if _, managed := FindBinaryInLockFile(lockFile, name); managed || strings.HasPrefix(name, "stew") {
+			continue
+		}
+		candidatePath := filepath.Join(dir, name)
+		if IsShim(candidatePath) {
+			continue
+		}
+		fileInfo, err := os.Stat(candidatePath)
+		if err != nil || fileInfo.IsDir() {
+			continue
+		}
+		if fileInfo.Mode()&0111 != 0 {
+			candidates = append(candidates, candidatePath)
+		}
+	}
+	return candidates, nil
+}
+
+// RankSearchResults orders the search results for a binary so that the repos with the same name as the binary come first
+func RankSearchResults(binary string, searchResults RepoSearch) RepoSearch {
+	rank := func(result RepoSearchResult) int {
+		_, repo, _ := strings.Cut(result.FullName, "/")
+		switch {
+		case strings.EqualFold(repo, binary):
+			return 0
+		case strings.Contains(strings.ToLower(repo), strings.ToLower(binary)):
+			return 1
+		}
+		return 2
+	}
+	ranked := searchResults
+	ranked.Items = append([]RepoSearchResult{}, searchResults.Items...)
+	sort.SliceStable(ranked.Items, func(i, j int) bool {
+		return rank(ranked.Items[i]) < rank(ranked.Items[j])
+	})
+	return ranked
+}
+
+// MatchAssetDigest finds the release asset whose SHA256 digest reported by the source matches a binary.
+// This only finds binaries that were downloaded as a bare asset rather than extracted from an archive.
+func MatchAssetDigest(releases []Release, binarySHA256 string) (string, string, bool) {
+	for _, release := range releases {
+		for _, asset := range release.Assets {
+			if digest, found := strings.CutPrefix(asset.Digest, "sha256:"); found && digest == binarySHA256 {
+				return release.TagName, asset.Name, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// MatchVersionOutput finds the release tag for a version printed by a binary [Ex: ripgrep 14.1.0 matches the tag 14.1.0]
+func MatchVersionOutput(output string, tags []string) (string, bool) {
+	for _, version := range versionRegex.FindAllString(output, -1) {
+		for _, tag := range tags {
+			start := strings.IndexFunc(tag, func(r rune) bool { return r >= '0' && r <= '9' })
+			if start != -1 && tag[start:] == version {
+				return tag, true
+			}
+		}
+	}
+	return "", false
+}
+
+// InferInstalledRelease infers the release that a binary came from. The binary is first matched against the digests of
+// the release assets, and then its --version output is matched against the release tags. The asset is only returned if
+// the binary matched one of the digests.
+func InferInstalledRelease(binaryPath string, releases []Release) (string, string, bool) {
+	if binarySHA256, err := SHA256File(binaryPath); err == nil {
+		if tag, asset, found := MatchAssetDigest(releases, binarySHA256); found {
+			return tag, asset, true
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), versionCommandTimeout)
+	defer cancel()
+	output, _ := exec.CommandContext(ctx, binaryPath, "--version").CombinedOutput()
+	tags := []string{}
+	for _, release := range releases {
+		tags = append(tags, release.TagName)
+	}
+	if tag, found := MatchVersionOutput(string(output), tags); found {
+		return tag, "", true
+	}
+	return "", "", false
+}
+
+// AdoptBinary copies an existing binary into the version directory of a package and links it into the ~/.stew/bin path,
+// so that it is managed by stew like any installed binary. It returns the package with the digest of the binary.
+func AdoptBinary(systemInfo SystemInfo, pkg PackageData, binaryPath string) (PackageData, error) {
+	if err := os.MkdirAll(systemInfo.StewTmpPath, 0755); err != nil {
+		return PackageData{}, err
+	}
+	tmpBinaryPath := filepath.Join(systemInfo.StewTmpPath, pkg.Binary)
+	if err := copyFile(binaryPath, tmpBinaryPath); err != nil {
+		return PackageData{}, err
+	}
+	defer os.Remove(tmpBinaryPath)
+
+	if _, err := installVersion(systemInfo, pkg.Binary, InstalledVersionName(pkg), tmpBinaryPath, nil); err != nil {
+		return PackageData{}, err
+	}
+	binarySHA256, err := InstalledBinarySHA256(systemInfo.StewPkgPath, pkg)
+	if err != nil {
+		return PackageData{}, err
+	}
+	pkg.BinarySHA256 = binarySHA256
+	return pkg, RecordInstalledVersion(systemInfo.StewPkgPath, pkg)
+}
@@ -0,0 +1,196 @@
+package stew
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestResolveBinaryPath(t *testing.T) {
+	tempDir := t.TempDir()
+	stewBinPath := filepath.Join(tempDir, "stew", "bin")
+	otherBinPath := filepath.Join(tempDir, "usr", "bin")
+	for _, path := range []string{stewBinPath, otherBinPath} {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{filepath.Join(stewBinPath, "rg"), filepath.Join(otherBinPath, "rg"), filepath.Join(otherBinPath, "fzf")} {
+		if err := os.WriteFile(file, []byte("binary"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "test1",
+			input: "rg",
+			want:  filepath.Join(stewBinPath, "rg"),
+		},
+		{
+			name:  "test2",
+			input: "fzf",
+			want:  filepath.Join(otherBinPath, "fzf"),
+		},
+		{
+			name:  "test3",
+			input: filepath.Join(otherBinPath, "rg"),
+			want:  filepath.Join(otherBinPath, "rg"),
+		},
+		{
+			name:    "test4",
+			input:   "bat",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveBinaryPath(tt.input, stewBinPath, otherBinPath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveBinaryPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveBinaryPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindAdoptionCandidates(t *testing.T) {
+	systemInfo := newTestVersionsSystemInfo(t)
+	installTestVersion(t, systemInfo, PackageData{Source: "github", Tag: "0.45.0", Asset: "fzf.tar.gz", Binary: "fzf"})
+	for name, perm := range map[string]os.FileMode{"rg": 0755, "stew": 0755, "README.md": 0644} {
+		if err := os.WriteFile(filepath.Join(systemInfo.StewBinPath, name), []byte(name), perm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lockFile := LockFile{Packages: []PackageData{{Binary: "fzf"}}}
+
+	got, err := FindAdoptionCandidates(systemInfo.StewBinPath, lockFile)
+	if err != nil {
+		t.Fatalf("FindAdoptionCandidates() error = %v", err)
+	}
+	if want := []string{filepath.Join(systemInfo.StewBinPath, "rg")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FindAdoptionCandidates() = %v, want %v", got, want)
+	}
+}
+
+func TestRankSearchResults(t *testing.T) {
+	searchResults := RepoSearch{Items: []RepoSearchResult{
+		{FullName: "someone/awesome-tools"},
+		{FullName: "microsoft/ripgrep-prebuilt"},
+		{FullName: "BurntSushi/ripgrep"},
+	}}
+	got := RankSearchResults("ripgrep", searchResults)
+	want := []RepoSearchResult{searchResults.Items[2], searchResults.Items[1], searchResults.Items[0]}
+	if !reflect.DeepEqual(got.Items, want) {
+		t.Errorf("RankSearchResults() = %v, want %v", got.Items, want)
+	}
+	if searchResults.Items[0].FullName != "someone/awesome-tools" {
+		t.Errorf("RankSearchResults() modified the search results")
+	}
+}
+
+func TestMatchAssetDigest(t *testing.T) {
+	releases := []Release{
+		{TagName: "v1.29.0", Assets: []ReleaseAsset{{Name: "kubectl-linux-amd64", Digest: "sha256:bbb"}}},
+		{TagName: "v1.28.0", Assets: []ReleaseAsset{{Name: "kubectl-linux-amd64", Digest: "sha256:aaa"}}},
+	}
+	tag, asset, found := MatchAssetDigest(releases, "aaa")
+	if !found || tag != "v1.28.0" || asset != "kubectl-linux-amd64" {
+		t.Errorf("MatchAssetDigest() = %v, %v, %v, want v1.28.0, kubectl-linux-amd64, true", tag, asset, found)
+	}
+	if _, _, found := MatchAssetDigest(releases, "ccc"); found {
+		t.Errorf("MatchAssetDigest() found a digest that isn't in any release")
+	}
+}
+
+func TestMatchVersionOutput(t *testing.T) {
+	tags := []string{"v14.1.0", "14.0.0", "jq-1.7.1", "nightly"}
+	tests := []struct {
+		name      string
+		output    string
+		want      string
+		wantFound bool
+	}{
+		{
+			name:      "test1",
+			output:    "ripgrep 14.1.0\n\nfeatures:+pcre2",
+			want:      "v14.1.0",
+			wantFound: true,
+		},
+		{
+			name:      "test2",
+			output:    "jq-1.7.1",
+			want:      "jq-1.7.1",
+			wantFound: true,
+		},
+		{
+			name:      "test3",
+			output:    "version 2.0.0",
+			wantFound: false,
+		},
+		{
+			name:      "test4",
+			output:    "",
+			wantFound: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := MatchVersionOutput(tt.output, tags)
+			if got != tt.want || found != tt.wantFound {
+				t.Errorf("MatchVersionOutput() = %v, %v, want %v, %v", got, found, tt.want, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestInferInstalledRelease(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test binary is a shell script")
+	}
+	binaryPath := filepath.Join(t.TempDir(), "fzf")
+	if err := os.WriteFile(binaryPath, []byte("#!/bin/sh\necho '0.45.0 (brew)'\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	releases := []Release{{TagName: "0.46.0"}, {TagName: "0.45.0"}}
+
+	tag, asset, found := InferInstalledRelease(binaryPath, releases)
+	if !found || tag != "0.45.0" || asset != "" {
+		t.Errorf("InferInstalledRelease() = %v, %v, %v, want 0.45.0, \"\", true", tag, asset, found)
+	}
+}
+
+func TestAdoptBinary(t *testing.T) {
+	systemInfo := newTestVersionsSystemInfo(t)
+	binaryPath := filepath.Join(systemInfo.StewBinPath, "rg")
+	if err := os.WriteFile(binaryPath, []byte("14.1.0"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	pkg := PackageData{Source: "github", Owner: "BurntSushi", Repo: "ripgrep", Tag: "14.1.0", Asset: "ripgrep.tar.gz", Binary: "rg"}
+
+	got, err := AdoptBinary(systemInfo, pkg, binaryPath)
+	if err != nil {
+		t.Fatalf("AdoptBinary() error = %v", err)
+	}
+	if got.BinarySHA256 != sha256Hex([]byte("14.1.0")) {
+		t.Errorf("AdoptBinary() recorded the digest %v", got.BinarySHA256)
+	}
+	if _, err := ReadInstalledVersion(systemInfo.StewPkgPath, "rg", "14.1.0"); err != nil {
+		t.Errorf("ReadInstalledVersion() error = %v", err)
+	}
+	if fileInfo, err := os.Lstat(binaryPath); err != nil || fileInfo.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("The adopted binary is not linked to its version directory")
+	}
+	if got := readTestBinary(t, binaryPath); got != "14.1.0" {
+		t.Errorf("The adopted binary is %v, want 14.1.0", got)
+	}
+}
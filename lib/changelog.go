@@ -0,0 +1,150 @@
+package stew
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/marwanhawari/stew/constants"
+)
+
+// ChangelogReleases returns the releases after the installed tag of a package up to and including the target tag,
+// newest first. Pre-releases are left out unless the target is one, since their notes are usually repeated in the
+// release that follows them. Tags that aren't numeric are taken in the order the source lists them.
+func ChangelogReleases(releases []Release, pkg PackageData, targetTag string) ([]Release, error) {
+	targetIndex, installedIndex := -1, len(releases)
+	for index, release := range releases {
+		if release.TagName == targetTag {
+			targetIndex = index
+		}
+		if release.TagName == pkg.Tag {
+			installedIndex = index
+		}
+	}
+	if targetIndex == -1 {
+		return []Release{}, TagNotFoundError{Tag: targetTag}
+	}
+	_, _, installedTagIsNumeric := splitTag(pkg.Tag)
+	_, _, targetTagIsNumeric := splitTag(targetTag)
+	compareTags := installedTagIsNumeric && targetTagIsNumeric
+	includePreReleases := IsPreReleaseTag(targetTag)
+
+	changelog := []Release{}
+	for index, release := range releases {
+		tag := release.TagName
+		if tag != targetTag && !includePreReleases && IsPreReleaseTag(tag) {
+			continue
+		}
+		if compareTags {
+			if _, _, numeric := splitTag(tag); !numeric || CompareTags(tag, pkg.Tag) <= 0 || CompareTags(tag, targetTag) > 0 {
+				continue
+			}
+		} else if index < targetIndex || index >= installedIndex {
+			continue
+		}
+		changelog = append(changelog, release)
+	}
+	if compareTags {
+		sort.SliceStable(changelog, func(i, j int) bool {
+			return CompareTags(changelog[i].TagName, changelog[j].TagName) > 0
+		})
+	}
+	return changelog, nil
+}
+
+var (
+	markdownHeadingRegex     = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	markdownListItemRegex    = regexp.MustCompile(`^(\s*)(?:[-*+]|\d+[.)])\s+(.*)$`)
+	markdownRuleRegex        = regexp.MustCompile(`^\s*(?:-{3,}|\*{3,}|_{3,})\s*$`)
+	markdownImageRegex       = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	markdownLinkRegex        = regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
+	markdownBoldRegex        = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	markdownCodeRegex        = regexp.MustCompile("`([^`]+)`")
+	markdownHTMLCommentRegex = regexp.MustCompile(`<!--.*?-->`)
+	breakingHeadingRegex     = regexp.MustCompile(`(?i)\bbreaking\b`)
+	breakingLineRegex        = regexp.MustCompile(`\bBREAKING\b|(?i:\bbreaking changes?\b)`)
+)
+
+// RenderMarkdown renders the Markdown of release notes for the terminal. Headings are colored, list items get bullets
+// and links show their URL. Sections with "breaking" in their heading and lines that mention breaking changes are
+// highlighted in red and marked with ⚠️.
+func RenderMarkdown(markdown string) string {
+	markdown = strings.ReplaceAll(markdown, "\r\n", "\n")
+	markdown = markdownHTMLCommentRegex.ReplaceAllString(markdown, "")
+
+	renderedLines := []string{}
+	inCodeBlock := false
+	breakingLevel := 0
+	for _, line := range strings.Split(strings.TrimSpace(markdown), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			renderedLines = append(renderedLines, "    "+line)
+			continue
+		}
+
+		if match := markdownHeadingRegex.FindStringSubmatch(line); match != nil {
+			level, text := len(match[1]), renderInlineMarkdown(match[2], false)
+			if breakingLevel != 0 && level <= breakingLevel {
+				breakingLevel = 0
+			}
+			if breakingHeadingRegex.MatchString(text) {
+				breakingLevel = level
+				renderedLines = append(renderedLines, constants.RedColor("⚠️  "+text))
+				continue
+			}
+			renderedLines = append(renderedLines, constants.GreenColor(text))
+			continue
+		}
+
+		if markdownRuleRegex.MatchString(line) {
+			renderedLines = append(renderedLines, strings.Repeat("─", 40))
+			continue
+		}
+
+		breaking := breakingLevel != 0 || breakingLineRegex.MatchString(line)
+		if match := markdownListItemRegex.FindStringSubmatch(line); match != nil {
+			line = match[1] + "• " + match[2]
+		}
+		line = renderInlineMarkdown(line, !breaking)
+		if breaking && strings.TrimSpace(line) != "" {
+			if breakingLevel == 0 {
+				line = "⚠️  " + line
+			}
+			line = constants.RedColor(line)
+		}
+		renderedLines = append(renderedLines, line)
+	}
+	return strings.Join(renderedLines, "\n")
+}
+
+// renderInlineMarkdown renders the images, links, bold text and code spans of a line. Colors are left out of lines
+// that are colored as a whole.
+func renderInlineMarkdown(line string, colored bool) string {
+	line = markdownImageRegex.ReplaceAllString(line, "$1")
+	line = markdownLinkRegex.ReplaceAllStringFunc(line, func(link string) string {
+		match := markdownLinkRegex.FindStringSubmatch(link)
+		if match[1] == match[2] {
+			return match[2]
+		}
+		return match[1] + " (" + match[2] + ")"
+	})
+	line = markdownBoldRegex.ReplaceAllStringFunc(line, func(bold string) string {
+		match := markdownBoldRegex.FindStringSubmatch(bold)
+		text := match[1] + match[2]
+		if colored {
+			return constants.BoldColor(text)
+		}
+		return text
+	})
+	line = markdownCodeRegex.ReplaceAllStringFunc(line, func(code string) string {
+		text := strings.Trim(code, "`")
+		if colored {
+			return constants.YellowColor(text)
+		}
+		return text
+	})
+	return line
+}
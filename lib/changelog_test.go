@@ -0,0 +1,114 @@
+package stew
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gookit/color"
+)
+
+func TestChangelogReleases(t *testing.T) {
+	releases := []Release{
+		{TagName: "v1.4.0-rc1"},
+		{TagName: "v1.3.1"},
+		{TagName: "v2.0.0"},
+		{TagName: "v1.3.0"},
+		{TagName: "v1.2.0"},
+		{TagName: "v1.1.0"},
+	}
+	codenames := []Release{{TagName: "oak"}, {TagName: "maple"}, {TagName: "elm"}}
+
+	tests := []struct {
+		name      string
+		releases  []Release
+		tag       string
+		targetTag string
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name:      "test1",
+			releases:  releases,
+			tag:       "v1.1.0",
+			targetTag: "v1.3.1",
+			want:      []string{"v1.3.1", "v1.3.0", "v1.2.0"},
+		},
+		{
+			name:      "test2",
+			releases:  releases,
+			tag:       "v1.3.0",
+			targetTag: "v2.0.0",
+			want:      []string{"v2.0.0", "v1.3.1"},
+		},
+		{
+			name:      "test3",
+			releases:  releases,
+			tag:       "v1.3.1",
+			targetTag: "v1.4.0-rc1",
+			want:      []string{"v1.4.0-rc1"},
+		},
+		{
+			name:      "test4",
+			releases:  codenames,
+			tag:       "elm",
+			targetTag: "oak",
+			want:      []string{"oak", "maple"},
+		},
+		{
+			name:      "test5",
+			releases:  releases,
+			tag:       "v1.1.0",
+			targetTag: "v3.0.0",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ChangelogReleases(tt.releases, PackageData{Tag: tt.tag}, tt.targetTag)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ChangelogReleases() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			gotTags := []string{}
+			for _, release := range got {
+				gotTags = append(gotTags, release.TagName)
+			}
+			if !reflect.DeepEqual(gotTags, tt.want) {
+				t.Errorf("ChangelogReleases() = %v, want %v", gotTags, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{
+			name:     "test1",
+			markdown: "## What's Changed\r\n* Add **fast** mode in `rg` by @someone in [#42](https://github.com/owner/repo/pull/42)\r\n",
+			want:     "What's Changed\n• Add fast mode in rg by @someone in #42 (https://github.com/owner/repo/pull/42)",
+		},
+		{
+			name:     "test2",
+			markdown: "## Breaking changes\n- Remove the `--old` flag\n\n## Fixes\n- Fix a crash",
+			want:     "⚠️  Breaking changes\n• Remove the --old flag\n\nFixes\n• Fix a crash",
+		},
+		{
+			name:     "test3",
+			markdown: "- BREAKING: rename the config file\n```sh\n# not a heading\n```\n---",
+			want:     "⚠️  • BREAKING: rename the config file\n    # not a heading\n" + "────────────────────────────────────────",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := color.ClearCode(RenderMarkdown(tt.markdown)); got != tt.want {
+				t.Errorf("RenderMarkdown() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -80,6 +80,13 @@ func GetStewConfigFilePath(userOS string) (string, error) {
 type StewConfig struct {
 	StewPath    string `json:"stewPath"`
 	StewBinPath string `json:"stewBinPath"`
+	// StewLockFilePath defaults to Stewfile.lock.json inside the stewPath
+	StewLockFilePath string `json:"stewLockFilePath,omitempty"`
+}
+
+// ReadStewConfigFile reads a stew.config.json file without filling in any defaults
+func ReadStewConfigFile(stewConfigFilePath string) (StewConfig, error) {
+	return readStewConfigJSON(stewConfigFilePath)
 }
 
 func readStewConfigJSON(stewConfigFilePath string) (StewConfig, error) {
@@ -93,10 +100,27 @@ func readStewConfigJSON(stewConfigFilePath string) (StewConfig, error) {
 	if err != nil {
 		return StewConfig{}, err
 	}
+	stewConfig.StewLockFilePath, err = ResolveStewLockFilePath(stewConfig.StewLockFilePath, stewConfig.StewPath)
+	if err != nil {
+		return StewConfig{}, err
+	}
 
 	return stewConfig, nil
 }
 
+// ResolveStewLockFilePath expands ~ and environment variables in the stewLockFilePath of a config. A relative
+// path is taken relative to the stewPath rather than the working directory, so every command finds the same lockfile.
+func ResolveStewLockFilePath(stewLockFilePath, stewPath string) (string, error) {
+	if stewLockFilePath == "" {
+		return "", nil
+	}
+	expandedPath := os.ExpandEnv(strings.ReplaceAll(stewLockFilePath, "\"", ""))
+	if !strings.HasPrefix(expandedPath, "~") && !filepath.IsAbs(expandedPath) && stewPath != "" {
+		expandedPath = filepath.Join(stewPath, expandedPath)
+	}
+	return ResolvePath(expandedPath)
+}
+
 // WriteStewConfigJSON will write the config JSON file
 func WriteStewConfigJSON(stewConfigFileJSON StewConfig, outputPath string) error {
 	stewConfigFileBytes, err := json.MarshalIndent(stewConfigFileJSON, "", "\t")
@@ -178,6 +202,10 @@ func createStewDirsAndFiles(stewConfig StewConfig, stewConfigFilePath string) er
 	if err != nil {
 		return err
 	}
+	err = os.MkdirAll(filepath.Join(stewConfig.StewPath, "assets"), 0755)
+	if err != nil {
+		return err
+	}
 
 	err = os.MkdirAll(stewConfig.StewBinPath, 0755)
 	if err != nil {
@@ -201,8 +229,13 @@ type SystemInfo struct {
 	StewPath         string
 	StewBinPath      string
 	StewPkgPath      string
+	StewAssetPath    string
 	StewLockFilePath string
 	StewTmpPath      string
+	// ProjectPath is the directory containing the .stew environment that is used, or empty for the global environment
+	ProjectPath string
+	// CopyBinaries copies the active version of each binary into the StewBinPath instead of linking it
+	CopyBinaries bool
 }
 
 // NewSystemInfo creates a new instance of the SystemInfo struct
@@ -211,11 +244,121 @@ func NewSystemInfo(stewConfig StewConfig) SystemInfo {
 	systemInfo.StewPath = stewConfig.StewPath
 	systemInfo.StewBinPath = stewConfig.StewBinPath
 	systemInfo.StewPkgPath = filepath.Join(stewConfig.StewPath, "pkg")
+	systemInfo.StewAssetPath = filepath.Join(stewConfig.StewPath, "assets")
 	systemInfo.StewLockFilePath = filepath.Join(stewConfig.StewPath, "Stewfile.lock.json")
+	if stewConfig.StewLockFilePath != "" {
+		systemInfo.StewLockFilePath = stewConfig.StewLockFilePath
+	}
 	systemInfo.StewTmpPath = filepath.Join(stewConfig.StewPath, "tmp")
 	return systemInfo
 }
 
+// ProjectStewDirName is the name of the directory that holds a project-local stew environment
+const ProjectStewDirName = ".stew"
+
+// NewProjectSystemInfo creates a SystemInfo for the project-local environment in projectStewPath.
+// All of the binaries, assets and the lockfile are kept inside of it.
+func NewProjectSystemInfo(projectStewPath string) SystemInfo {
+	return SystemInfo{
+		StewPath:         projectStewPath,
+		StewBinPath:      filepath.Join(projectStewPath, "bin"),
+		StewPkgPath:      filepath.Join(projectStewPath, "pkg"),
+		StewAssetPath:    filepath.Join(projectStewPath, "assets"),
+		StewLockFilePath: filepath.Join(projectStewPath, "Stewfile.lock.json"),
+		StewTmpPath:      filepath.Join(projectStewPath, "tmp"),
+		ProjectPath:      filepath.Dir(projectStewPath),
+	}
+}
+
+// FindProjectStewPath looks for a .stew directory in startDir and each of its parents. The search stops below the
+// home directory, so a ~/.stew directory left over from older versions of stew is never used as a project.
+func FindProjectStewPath(startDir string) (string, bool, error) {
+	dir, err := filepath.Abs(startDir)
+	if err != nil {
+		return "", false, err
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", false, err
+	}
+	for {
+		if samePath(dir, homeDir) {
+			return "", false, nil
+		}
+		projectStewPath := filepath.Join(dir, ProjectStewDirName)
+		fileInfo, err := os.Stat(projectStewPath)
+		if err == nil && fileInfo.IsDir() {
+			return projectStewPath, true, nil
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return "", false, err
+		}
+		parentDir := filepath.Dir(dir)
+		if parentDir == dir {
+			return "", false, nil
+		}
+		dir = parentDir
+	}
+}
+
+// InitProjectEnvironment creates a project-local stew environment in projectPath. The binaries and assets are
+// ignored by git so that only the lockfile is committed.
+func InitProjectEnvironment(projectPath string) (string, error) {
+	projectStewPath := filepath.Join(projectPath, ProjectStewDirName)
+	projectSystemInfo := NewProjectSystemInfo(projectStewPath)
+	for _, path := range []string{projectSystemInfo.StewBinPath, projectSystemInfo.StewPkgPath, projectSystemInfo.StewAssetPath} {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			return "", err
+		}
+	}
+	gitignorePath := filepath.Join(projectStewPath, ".gitignore")
+	gitignoreExists, err := PathExists(gitignorePath)
+	if err != nil {
+		return "", err
+	}
+	if !gitignoreExists {
+		if err := os.WriteFile(gitignorePath, []byte("bin/\npkg/\nassets/\ntmp/\nrollback.json\nhistory.jsonl\n"), 0644); err != nil {
+			return "", err
+		}
+	}
+	return projectStewPath, nil
+}
+
+// NewTargetSystemInfo creates a copy of the SystemInfo that installs binaries for targetOS/targetArch into targetBinPath.
+// The lockfile for the target is kept inside targetBinPath, and the binaries are copied rather than linked, so that
+// targetBinPath can be copied elsewhere. Their versions and assets are kept apart from the ones of the host.
+func NewTargetSystemInfo(systemInfo SystemInfo, targetBinPath, targetOS, targetArch string) (SystemInfo, error) {
+	resolvedBinPath, err := ResolvePath(targetBinPath)
+	if err != nil {
+		return SystemInfo{}, err
+	}
+	err = os.MkdirAll(resolvedBinPath, 0755)
+	if err != nil {
+		return SystemInfo{}, err
+	}
+	targetStewPath := filepath.Join(systemInfo.StewPath, "targets", targetOS+"-"+targetArch)
+	systemInfo.StewBinPath = resolvedBinPath
+	systemInfo.StewPkgPath = filepath.Join(targetStewPath, "pkg")
+	systemInfo.StewAssetPath = filepath.Join(targetStewPath, "assets")
+	systemInfo.StewLockFilePath = filepath.Join(resolvedBinPath, "Stewfile.lock.json")
+	systemInfo.CopyBinaries = true
+	return systemInfo, nil
+}
+
+var supportedOperatingSystems = []string{"darwin", "linux", "windows", "freebsd", "netbsd", "openbsd", "android"}
+
+var supportedArchitectures = []string{"amd64", "arm64", "386", "arm", "ppc64le", "s390x", "riscv64"}
+
+// ValidatePlatform makes sure the OS/arch pair is one that stew knows how to resolve assets for
+func ValidatePlatform(userOS, userArch string) error {
+	_, osFound := Contains(supportedOperatingSystems, userOS)
+	_, archFound := Contains(supportedArchitectures, userArch)
+	if !osFound || !archFound {
+		return UnsupportedPlatformError{OS: userOS, Arch: userArch}
+	}
+	return nil
+}
+
 // Initialize returns pertinent initialization information like OS, arch, configuration, and system info
 func Initialize() (string, string, StewConfig, SystemInfo, error) {
 	userOS := runtime.GOOS
@@ -224,11 +367,55 @@ func Initialize() (string, string, StewConfig, SystemInfo, error) {
 	if err != nil {
 		return "", "", StewConfig{}, SystemInfo{}, err
 	}
-	systemInfo := NewSystemInfo(stewConfig)
+	systemInfo, err := ResolveSystemInfo(stewConfig)
+	if err != nil {
+		return "", "", StewConfig{}, SystemInfo{}, err
+	}
+
+	return userOS, userArch, stewConfig, systemInfo, nil
+}
 
+// InitializeQuiet is like Initialize, but it never prompts or prints. It is used by shims, whose output
+// belongs to the binary they run, so the stew.config.json file has to exist already.
+func InitializeQuiet() (string, string, StewConfig, SystemInfo, error) {
+	userOS := runtime.GOOS
+	userArch := runtime.GOARCH
+	stewConfigFilePath, err := GetStewConfigFilePath(userOS)
+	if err != nil {
+		return "", "", StewConfig{}, SystemInfo{}, err
+	}
+	stewConfig, err := ReadStewConfigFile(stewConfigFilePath)
+	if err != nil {
+		return "", "", StewConfig{}, SystemInfo{}, err
+	}
+	systemInfo, err := ResolveSystemInfo(stewConfig)
+	if err != nil {
+		return "", "", StewConfig{}, SystemInfo{}, err
+	}
 	return userOS, userArch, stewConfig, systemInfo, nil
 }
 
+// ResolveSystemInfo returns the SystemInfo of the environment to use. A .stew directory in the working directory
+// or one of its parents takes precedence over the global environment, unless STEW_GLOBAL is set.
+func ResolveSystemInfo(stewConfig StewConfig) (SystemInfo, error) {
+	systemInfo := NewSystemInfo(stewConfig)
+	if os.Getenv("STEW_GLOBAL") != "" {
+		return systemInfo, nil
+	}
+	workingDir, err := os.Getwd()
+	if err != nil {
+		return SystemInfo{}, err
+	}
+	projectStewPath, found, err := FindProjectStewPath(workingDir)
+	if err != nil {
+		return SystemInfo{}, err
+	}
+	if found && projectStewPath != stewConfig.StewPath {
+		systemInfo = NewProjectSystemInfo(projectStewPath)
+	}
+	return systemInfo, nil
+}
+
 // PromptConfig launches an interactive UI for setting the stew config values. It returns the resolved stewPath and stewBinPath.
 func PromptConfig(suggestedStewPath, suggestedStewBinPath string) (string, string, error) {
 	inputStewPath, err := PromptInput(
@@ -259,7 +446,7 @@ func PromptConfig(suggestedStewPath, suggestedStewBinPath string) (string, strin
 }
 
 func ValidateStewBinPath(stewBinPath, pathVariable string) bool {
-	if !strings.Contains(pathVariable, stewBinPath) {
+	if !PathListContains(pathVariable, stewBinPath) {
 		fmt.Printf(
 			"%v The stewBinPath %v is not in your PATH variable.\nYou need to add %v to PATH.\n",
 			constants.YellowColor("WARNING:"),
@@ -275,3 +462,17 @@ func ValidateStewBinPath(stewBinPath, pathVariable string) bool {
 
 	return true
 }
+
+// FormatPathAddition returns the shell command that adds binPath to the front of the PATH
+func FormatPathAddition(binPath, shell string) (string, error) {
+	switch shell {
+	case "", "sh", "bash", "zsh":
+		return fmt.Sprintf("export PATH=\"%v:$PATH\"", binPath), nil
+	case "fish":
+		return fmt.Sprintf("fish_add_path --path --prepend %v", binPath), nil
+	case "powershell", "pwsh":
+		return fmt.Sprintf("$env:PATH = \"%v;\" + $env:PATH", binPath), nil
+	default:
+		return "", UnsupportedShellError{Shell: shell}
+	}
+}
@@ -50,3 +50,265 @@ func TestGetDefaultStewPath(t *testing.T) {
 		})
 	}
 }
+
+func TestNewTargetSystemInfo(t *testing.T) {
+	tempDir := t.TempDir()
+	systemInfo := SystemInfo{
+		StewPath:         filepath.Join(tempDir, "stew"),
+		StewBinPath:      filepath.Join(tempDir, "bin"),
+		StewPkgPath:      filepath.Join(tempDir, "stew", "pkg"),
+		StewAssetPath:    filepath.Join(tempDir, "stew", "assets"),
+		StewLockFilePath: filepath.Join(tempDir, "stew", "Stewfile.lock.json"),
+		StewTmpPath:      filepath.Join(tempDir, "stew", "tmp"),
+	}
+	targetBinPath := filepath.Join(tempDir, "docker", "bin")
+
+	got, err := NewTargetSystemInfo(systemInfo, targetBinPath, "linux", "arm64")
+	if err != nil {
+		t.Fatalf("NewTargetSystemInfo() error = %v", err)
+	}
+	want := systemInfo
+	want.StewBinPath = targetBinPath
+	want.StewPkgPath = filepath.Join(tempDir, "stew", "targets", "linux-arm64", "pkg")
+	want.StewAssetPath = filepath.Join(tempDir, "stew", "targets", "linux-arm64", "assets")
+	want.StewLockFilePath = filepath.Join(targetBinPath, "Stewfile.lock.json")
+	want.CopyBinaries = true
+	if got != want {
+		t.Errorf("NewTargetSystemInfo() = %v, want %v", got, want)
+	}
+	if binPathExists, _ := PathExists(targetBinPath); !binPathExists {
+		t.Errorf("The target bin path %v was not created", targetBinPath)
+	}
+}
+
+func TestValidatePlatform(t *testing.T) {
+	type args struct {
+		userOS   string
+		userArch string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "test1",
+			args: args{
+				userOS:   "linux",
+				userArch: "arm64",
+			},
+			wantErr: false,
+		},
+		{
+			name: "test2",
+			args: args{
+				userOS:   "plan10",
+				userArch: "amd64",
+			},
+			wantErr: true,
+		},
+		{
+			name: "test3",
+			args: args{
+				userOS:   "darwin",
+				userArch: "x86_64",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidatePlatform(tt.args.userOS, tt.args.userArch); (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePlatform() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewSystemInfo_lockFilePath(t *testing.T) {
+	tempDir := t.TempDir()
+	stewConfig := StewConfig{
+		StewPath:    filepath.Join(tempDir, "stew"),
+		StewBinPath: filepath.Join(tempDir, "bin"),
+	}
+	if got := NewSystemInfo(stewConfig).StewLockFilePath; got != filepath.Join(tempDir, "stew", "Stewfile.lock.json") {
+		t.Errorf("NewSystemInfo() StewLockFilePath = %v", got)
+	}
+	stewConfig.StewLockFilePath = filepath.Join(tempDir, "dotfiles", "Stewfile.lock.json")
+	if got := NewSystemInfo(stewConfig).StewLockFilePath; got != stewConfig.StewLockFilePath {
+		t.Errorf("NewSystemInfo() StewLockFilePath = %v, want %v", got, stewConfig.StewLockFilePath)
+	}
+}
+
+func TestResolveStewLockFilePath(t *testing.T) {
+	tempDir := t.TempDir()
+	homeDir := filepath.Join(tempDir, "home")
+	stewPath := filepath.Join(tempDir, "stew")
+	t.Setenv("HOME", homeDir)
+	t.Setenv("DOTFILES", filepath.Join(tempDir, "dotfiles"))
+
+	tests := []struct {
+		name             string
+		stewLockFilePath string
+		want             string
+	}{
+		{
+			name:             "test1",
+			stewLockFilePath: "",
+			want:             "",
+		},
+		{
+			name:             "test2",
+			stewLockFilePath: "~/dotfiles/Stewfile.lock.json",
+			want:             filepath.Join(homeDir, "dotfiles", "Stewfile.lock.json"),
+		},
+		{
+			name:             "test3",
+			stewLockFilePath: "locks/Stewfile.lock.json",
+			want:             filepath.Join(stewPath, "locks", "Stewfile.lock.json"),
+		},
+		{
+			name:             "test4",
+			stewLockFilePath: "$DOTFILES/Stewfile.lock.json",
+			want:             filepath.Join(tempDir, "dotfiles", "Stewfile.lock.json"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveStewLockFilePath(tt.stewLockFilePath, stewPath)
+			if err != nil {
+				t.Fatalf("ResolveStewLockFilePath() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveStewLockFilePath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindProjectStewPath(t *testing.T) {
+	tempDir := t.TempDir()
+	projectPath := filepath.Join(tempDir, "project")
+	nestedPath := filepath.Join(projectPath, "src", "cmd")
+	if err := os.MkdirAll(nestedPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	projectStewPath, err := InitProjectEnvironment(projectPath)
+	if err != nil {
+		t.Fatalf("InitProjectEnvironment() error = %v", err)
+	}
+	homeDir := filepath.Join(tempDir, "home")
+	homeNestedPath := filepath.Join(homeDir, "src", "tool")
+	for _, path := range []string{filepath.Join(homeDir, ProjectStewDirName), homeNestedPath} {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("HOME", homeDir)
+
+	tests := []struct {
+		name      string
+		startDir  string
+		want      string
+		wantFound bool
+	}{
+		{
+			name:      "test1",
+			startDir:  projectPath,
+			want:      projectStewPath,
+			wantFound: true,
+		},
+		{
+			name:      "test2",
+			startDir:  nestedPath,
+			want:      projectStewPath,
+			wantFound: true,
+		},
+		{
+			name:      "test3",
+			startDir:  tempDir,
+			want:      "",
+			wantFound: false,
+		},
+		{
+			name:      "test4",
+			startDir:  homeNestedPath,
+			want:      "",
+			wantFound: false,
+		},
+		{
+			name:      "test5",
+			startDir:  homeDir,
+			want:      "",
+			wantFound: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found, err := FindProjectStewPath(tt.startDir)
+			if err != nil {
+				t.Fatalf("FindProjectStewPath() error = %v", err)
+			}
+			if got != tt.want || found != tt.wantFound {
+				t.Errorf("FindProjectStewPath() = %v, %v, want %v, %v", got, found, tt.want, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestNewProjectSystemInfo(t *testing.T) {
+	projectStewPath := filepath.Join("project", ".stew")
+	want := SystemInfo{
+		StewPath:         projectStewPath,
+		StewBinPath:      filepath.Join(projectStewPath, "bin"),
+		StewPkgPath:      filepath.Join(projectStewPath, "pkg"),
+		StewAssetPath:    filepath.Join(projectStewPath, "assets"),
+		StewLockFilePath: filepath.Join(projectStewPath, "Stewfile.lock.json"),
+		StewTmpPath:      filepath.Join(projectStewPath, "tmp"),
+		ProjectPath:      "project",
+	}
+	if got := NewProjectSystemInfo(projectStewPath); got != want {
+		t.Errorf("NewProjectSystemInfo() = %v, want %v", got, want)
+	}
+}
+
+func TestFormatPathAddition(t *testing.T) {
+	tests := []struct {
+		name    string
+		shell   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "test1",
+			shell: "sh",
+			want:  `export PATH="/project/.stew/bin:$PATH"`,
+		},
+		{
+			name:  "test2",
+			shell: "fish",
+			want:  "fish_add_path --path --prepend /project/.stew/bin",
+		},
+		{
+			name:  "test3",
+			shell: "powershell",
+			want:  `$env:PATH = "/project/.stew/bin;" + $env:PATH`,
+		},
+		{
+			name:    "test4",
+			shell:   "tcsh",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FormatPathAddition("/project/.stew/bin", tt.shell)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FormatPathAddition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("FormatPathAddition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
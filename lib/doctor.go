@@ -0,0 +1,306 @@
+package stew
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// The severities of the findings of stew doctor
+const (
+	DoctorError   = "error"
+	DoctorWarning = "warning"
+	DoctorInfo    = "info"
+)
+
+// DoctorFinding is a problem found by stew doctor along with a suggested fix
+type DoctorFinding struct {
+	Severity string
+	Problem  string
+	Fix      string
+	fix      func() error
+}
+
+// CanFix checks if stew doctor --fix can fix the problem
+func (f DoctorFinding) CanFix() bool {
+	return f.fix != nil
+}
+
+// ApplyFix fixes the problem
+func (f DoctorFinding) ApplyFix() error {
+	if f.fix == nil {
+		return nil
+	}
+	return f.fix()
+}
+
+// PathListContains checks if a directory is one of the entries of a PATH variable. Entries are compared as
+// cleaned paths rather than substrings, so /usr/local/bin is not mistaken for /usr/local/bin2.
+func PathListContains(pathVariable, dir string) bool {
+	for _, entry := range filepath.SplitList(pathVariable) {
+		if samePath(entry, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+func samePath(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	a, b = filepath.Clean(a), filepath.Clean(b)
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// FindShadowingBinary looks for an executable with the same name as a binary in the PATH entries before the stewBinPath
+func FindShadowingBinary(pathVariable, stewBinPath, binary string) (string, bool) {
+	return lookPathBefore(pathVariable, stewBinPath, binary)
+}
+
+// LookPath finds the executable that a binary name resolves to in a PATH variable
+func LookPath(pathVariable, binary string) (string, bool) {
+	return lookPathBefore(pathVariable, "", binary)
+}
+
+// lookPathBefore finds an executable in the PATH entries before the stopDir
+func lookPathBefore(pathVariable, stopDir, binary string) (string, bool) {
+	names := []string{binary}
+	if runtime.GOOS == "windows" && filepath.Ext(binary) == "" {
+		names = append(names, binary+".exe")
+	}
+	for _, entry := range filepath.SplitList(pathVariable) {
+		if samePath(entry, stopDir) {
+			return "", false
+		}
+		for _, name := range names {
+			candidate := filepath.Join(entry, name)
+			fileInfo, err := os.Stat(candidate)
+			if err != nil || fileInfo.IsDir() {
+				continue
+			}
+			if runtime.GOOS == "windows" || fileInfo.Mode()&0111 != 0 {
+				return candidate, true
+			}
+		}
+	}
+	return "", false
+}
+
+// FindOrphanedPkgFiles returns the files in the ~/.stew/pkg and ~/.stew/assets paths that don't belong to any binary
+// in the lockfile
+func FindOrphanedPkgFiles(systemInfo SystemInfo, lockFile LockFile) ([]string, error) {
+	ownedBinaries := map[string]bool{}
+	ownedAssets := map[string]bool{}
+	for _, pkg := range lockFile.Packages {
+		ownedAssets[pkg.Asset] = true
+		if pkg.Binary == "" {
+			continue
+		}
+		ownedBinaries[pkg.Binary] = true
+		versions, err := ListInstalledVersions(systemInfo.StewPkgPath, pkg.Binary)
+		if err != nil {
+			return nil, err
+		}
+		for _, version := range versions {
+			versionPkg, err := ReadInstalledVersion(systemInfo.StewPkgPath, pkg.Binary, version)
+			if err != nil {
+				return nil, err
+			}
+			ownedAssets[versionPkg.Asset] = true
+		}
+	}
+
+	orphanedFiles := []string{}
+	for _, dir := range []struct {
+		path  string
+		owned map[string]bool
+	}{
+		{systemInfo.StewPkgPath, ownedBinaries},
+		{systemInfo.StewAssetPath, ownedAssets},
+	} {
+		entries, err := os.ReadDir(dir.path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if !dir.owned[entry.Name()] {
+				orphanedFiles = append(orphanedFiles, filepath.Join(dir.path, entry.Name()))
+			}
+		}
+	}
+	return orphanedFiles, nil
+}
+
+// hostTokenVariable returns the environment variable that holds the token for a host
+func hostTokenVariable(source, host string) string {
+	if source == "github" {
+		return "GITHUB_TOKEN"
+	}
+	return strings.ToUpper(strings.ReplaceAll(host, ".", "_")) + "_TOKEN"
+}
+
+// DiagnoseConfig checks that the stew.config.json file can be read
+func DiagnoseConfig(stewConfigFilePath string) (StewConfig, []DoctorFinding) {
+	stewConfig, err := ReadStewConfigFile(stewConfigFilePath)
+	if err != nil {
+		return StewConfig{}, []DoctorFinding{{
+			Severity: DoctorError,
+			Problem:  fmt.Sprintf("The config file %v can't be read: %v", stewConfigFilePath, err),
+			Fix:      "Run stew config to write a new config file",
+		}}
+	}
+	return stewConfig, []DoctorFinding{}
+}
+
+// DiagnoseInstallation reads the lockfile of a SystemInfo and checks the environment against it. When the
+// lockfile can't be read, only the PATH is checked, since every installed file would look orphaned otherwise.
+func DiagnoseInstallation(systemInfo SystemInfo, userOS, userArch, pathVariable string) ([]DoctorFinding, error) {
+	lockFile, err := NewLockFile(systemInfo.StewLockFilePath, userOS, userArch)
+	if err != nil {
+		findings := diagnosePath(systemInfo.StewBinPath, pathVariable)
+		return append(findings, DoctorFinding{
+			Severity: DoctorError,
+			Problem:  fmt.Sprintf("The lockfile %v can't be read: %v", systemInfo.StewLockFilePath, err),
+			Fix:      "Run stew lock migrate if it was written by an older version of stew, or restore it from a backup",
+		}), nil
+	}
+	return DiagnoseLockFile(systemInfo, lockFile, pathVariable)
+}
+
+// diagnosePath checks that the stewBinPath is in a PATH variable
+func diagnosePath(stewBinPath, pathVariable string) []DoctorFinding {
+	if PathListContains(pathVariable, stewBinPath) {
+		return []DoctorFinding{}
+	}
+	pathAddition, _ := FormatPathAddition(stewBinPath, "sh")
+	return []DoctorFinding{{
+		Severity: DoctorError,
+		Problem:  fmt.Sprintf("The stewBinPath %v is not in your PATH variable", stewBinPath),
+		Fix:      fmt.Sprintf("Add %v to your ~/.zshrc or ~/.bashrc file", pathAddition),
+	}}
+}
+
+// DiagnoseLockFile checks the environment described by a SystemInfo against the binaries in its lockfile
+func DiagnoseLockFile(systemInfo SystemInfo, lockFile LockFile, pathVariable string) ([]DoctorFinding, error) {
+	stewBinPath := systemInfo.StewBinPath
+	findings := diagnosePath(stewBinPath, pathVariable)
+
+	for _, pkg := range lockFile.Packages {
+		// Entries that were only locked for other platforms were never installed
+		if pkg.Binary == "" {
+			continue
+		}
+		findings = append(findings, diagnoseBinary(systemInfo, pkg)...)
+		if shadowingBinary, shadowed := FindShadowingBinary(pathVariable, stewBinPath, pkg.Binary); shadowed {
+			findings = append(findings, DoctorFinding{
+				Severity: DoctorWarning,
+				Problem:  fmt.Sprintf("The %v binary is shadowed by %v, which comes earlier in your PATH", pkg.Binary, shadowingBinary),
+				Fix:      fmt.Sprintf("Remove %v or move %v before %v in your PATH", shadowingBinary, stewBinPath, filepath.Dir(shadowingBinary)),
+			})
+		}
+	}
+
+	orphanedFiles, err := FindOrphanedPkgFiles(systemInfo, lockFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, orphanedFile := range orphanedFiles {
+		findings = append(findings, DoctorFinding{
+			Severity: DoctorWarning,
+			Problem:  fmt.Sprintf("%v doesn't belong to any installed binary", orphanedFile),
+			Fix:      "Delete it",
+			fix:      func() error { return os.RemoveAll(orphanedFile) },
+		})
+	}
+
+	if tmpEntries, err := os.ReadDir(systemInfo.StewTmpPath); err == nil && len(tmpEntries) > 0 {
+		findings = append(findings, DoctorFinding{
+			Severity: DoctorWarning,
+			Problem:  fmt.Sprintf("%v contains files left over from an interrupted install", systemInfo.StewTmpPath),
+			Fix:      "Delete them",
+			fix:      func() error { return os.RemoveAll(systemInfo.StewTmpPath) },
+		})
+	}
+
+	return append(findings, diagnoseTokens(lockFile)...), nil
+}
+
+// diagnoseBinary checks that an installed binary exists in the ~/.stew/bin path and is executable
+func diagnoseBinary(systemInfo SystemInfo, pkg PackageData) []DoctorFinding {
+	binaryPath := filepath.Join(systemInfo.StewBinPath, pkg.Binary)
+	versionPath := InstalledVersionPath(systemInfo.StewPkgPath, pkg.Binary, InstalledVersionName(pkg))
+	fileInfo, err := os.Stat(binaryPath)
+	if err != nil {
+		finding := DoctorFinding{
+			Severity: DoctorError,
+			Problem:  fmt.Sprintf("The %v binary is missing from %v", pkg.Binary, systemInfo.StewBinPath),
+			Fix:      fmt.Sprintf("Run stew install %v", GetPackageReference(pkg).Input),
+		}
+		if _, lstatErr := os.Lstat(binaryPath); lstatErr == nil {
+			finding.Problem = fmt.Sprintf("The %v binary in %v is a broken link", pkg.Binary, systemInfo.StewBinPath)
+		}
+		if versionInstalled, _ := PathExists(filepath.Join(versionPath, pkg.Binary)); versionInstalled {
+			finding.Fix = fmt.Sprintf("Link version %v back into %v", InstalledVersionName(pkg), systemInfo.StewBinPath)
+			finding.fix = func() error {
+				return activateVersion(systemInfo, versionPath, pkg.Binary, pkg.InstalledExtraFiles)
+			}
+		}
+		return []DoctorFinding{finding}
+	}
+	if runtime.GOOS != "windows" && fileInfo.Mode()&0111 == 0 {
+		return []DoctorFinding{{
+			Severity: DoctorError,
+			Problem:  fmt.Sprintf("The %v binary is not executable", pkg.Binary),
+			Fix:      fmt.Sprintf("Run chmod +x %v", binaryPath),
+			fix:      func() error { return os.Chmod(binaryPath, 0755) },
+		}}
+	}
+	return []DoctorFinding{}
+}
+
+// diagnoseTokens reports the hosts of the installed binaries that have no token configured, and tokens that have stray whitespace
+func diagnoseTokens(lockFile LockFile) []DoctorFinding {
+	hosts := map[string]string{}
+	for _, pkg := range lockFile.Packages {
+		switch pkg.Source {
+		case "github":
+			hosts["github.com"] = hostTokenVariable(pkg.Source, "")
+		case "gitlab", "gitea":
+			host := GetPackageReference(pkg).Host
+			hosts[host] = hostTokenVariable(pkg.Source, host)
+		}
+	}
+	sortedHosts := []string{}
+	for host := range hosts {
+		sortedHosts = append(sortedHosts, host)
+	}
+	sort.Strings(sortedHosts)
+
+	findings := []DoctorFinding{}
+	for _, host := range sortedHosts {
+		tokenVariable := hosts[host]
+		token := os.Getenv(tokenVariable)
+		switch {
+		case token == "":
+			findings = append(findings, DoctorFinding{
+				Severity: DoctorInfo,
+				Problem:  fmt.Sprintf("No token is configured for %v, so private repositories can't be installed and rate limits are lower", host),
+				Fix:      fmt.Sprintf("Set the %v environment variable", tokenVariable),
+			})
+		case strings.TrimSpace(token) != token:
+			findings = append(findings, DoctorFinding{
+				Severity: DoctorWarning,
+				Problem:  fmt.Sprintf("The %v environment variable has leading or trailing whitespace", tokenVariable),
+				Fix:      fmt.Sprintf("Remove the whitespace from %v", tokenVariable),
+			})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,199 @@
+package stew
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPathListContains(t *testing.T) {
+	pathSeparator := string(os.PathListSeparator)
+	tests := []struct {
+		name         string
+		pathVariable string
+		dir          string
+		want         bool
+	}{
+		{
+			name:         "test1",
+			pathVariable: strings.Join([]string{"/usr/bin", "/home/user/.local/bin"}, pathSeparator),
+			dir:          "/home/user/.local/bin",
+			want:         true,
+		},
+		{
+			name:         "test2",
+			pathVariable: strings.Join([]string{"/usr/bin", "/home/user/.local/bin2"}, pathSeparator),
+			dir:          "/home/user/.local/bin",
+			want:         false,
+		},
+		{
+			name:         "test3",
+			pathVariable: strings.Join([]string{"/usr/bin", "/home/user/.local/bin/"}, pathSeparator),
+			dir:          "/home/user/.local/bin",
+			want:         true,
+		},
+		{
+			name:         "test4",
+			pathVariable: "",
+			dir:          "/home/user/.local/bin",
+			want:         false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PathListContains(tt.pathVariable, tt.dir); got != tt.want {
+				t.Errorf("PathListContains() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindShadowingBinary(t *testing.T) {
+	tempDir := t.TempDir()
+	earlierPath := filepath.Join(tempDir, "usr", "bin")
+	stewBinPath := filepath.Join(tempDir, "stew", "bin")
+	laterPath := filepath.Join(tempDir, "opt", "bin")
+	for _, path := range []string{earlierPath, stewBinPath, laterPath} {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{filepath.Join(earlierPath, "rg"), filepath.Join(laterPath, "fzf")} {
+		if err := os.WriteFile(file, []byte("binary"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(earlierPath, "bat"), []byte("not executable"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pathVariable := strings.Join([]string{earlierPath, stewBinPath, laterPath}, string(os.PathListSeparator))
+
+	tests := []struct {
+		name      string
+		binary    string
+		want      string
+		wantFound bool
+	}{
+		{
+			name:      "test1",
+			binary:    "rg",
+			want:      filepath.Join(earlierPath, "rg"),
+			wantFound: true,
+		},
+		{
+			name:      "test2",
+			binary:    "fzf",
+			wantFound: false,
+		},
+		{
+			name:      "test3",
+			binary:    "bat",
+			wantFound: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := FindShadowingBinary(pathVariable, stewBinPath, tt.binary)
+			if got != tt.want || found != tt.wantFound {
+				t.Errorf("FindShadowingBinary() = %v, %v, want %v, %v", got, found, tt.want, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestFindOrphanedPkgFiles(t *testing.T) {
+	systemInfo := newTestVersionsSystemInfo(t)
+	oldPkg := PackageData{Source: "github", Owner: "junegunn", Repo: "fzf", Tag: "0.44.0", Asset: "fzf-0.44.0.tar.gz", Binary: "fzf"}
+	newPkg := PackageData{Source: "github", Owner: "junegunn", Repo: "fzf", Tag: "0.45.0", Asset: "fzf-0.45.0.tar.gz", Binary: "fzf"}
+	installTestVersion(t, systemInfo, oldPkg)
+	installTestVersion(t, systemInfo, newPkg)
+	for _, asset := range []string{oldPkg.Asset, newPkg.Asset, "ripgrep-14.0.0.tar.gz"} {
+		if err := os.WriteFile(filepath.Join(systemInfo.StewAssetPath, asset), []byte("asset"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := FindOrphanedPkgFiles(systemInfo, LockFile{Packages: []PackageData{newPkg}})
+	if err != nil {
+		t.Fatalf("FindOrphanedPkgFiles() error = %v", err)
+	}
+	want := []string{filepath.Join(systemInfo.StewAssetPath, "ripgrep-14.0.0.tar.gz")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindOrphanedPkgFiles() = %v, want %v", got, want)
+	}
+}
+
+func TestDiagnoseLockFile(t *testing.T) {
+	systemInfo := newTestVersionsSystemInfo(t)
+	pkg := PackageData{Source: "github", Owner: "junegunn", Repo: "fzf", Tag: "0.45.0", Asset: "fzf-0.45.0.tar.gz", Binary: "fzf"}
+	installTestVersion(t, systemInfo, pkg)
+	lockFile := LockFile{Packages: []PackageData{pkg}}
+	binaryPath := filepath.Join(systemInfo.StewBinPath, "fzf")
+	if err := os.Remove(binaryPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(systemInfo.StewTmpPath, "leftover"), []byte("leftover"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	findings, err := DiagnoseLockFile(systemInfo, lockFile, systemInfo.StewBinPath)
+	if err != nil {
+		t.Fatalf("DiagnoseLockFile() error = %v", err)
+	}
+	fixable := 0
+	for _, finding := range findings {
+		if finding.CanFix() {
+			fixable++
+			if err := finding.ApplyFix(); err != nil {
+				t.Fatalf("ApplyFix() error = %v", err)
+			}
+		}
+	}
+	if fixable != 2 {
+		t.Errorf("DiagnoseLockFile() found %v fixable problems, want 2: %v", fixable, findings)
+	}
+	if got := readTestBinary(t, binaryPath); got != "0.45.0" {
+		t.Errorf("The relinked binary is %v, want 0.45.0", got)
+	}
+	if tmpExists, _ := PathExists(systemInfo.StewTmpPath); tmpExists {
+		t.Errorf("The leftover tmp files were not deleted")
+	}
+
+	findings, err = DiagnoseLockFile(systemInfo, lockFile, "")
+	if err != nil {
+		t.Fatalf("DiagnoseLockFile() error = %v", err)
+	}
+	if len(findings) == 0 || findings[0].Severity != DoctorError || !strings.Contains(findings[0].Problem, "PATH") {
+		t.Errorf("DiagnoseLockFile() didn't report the stewBinPath missing from the PATH: %v", findings)
+	}
+}
+
+func TestDiagnoseInstallation_UnreadableLockFile(t *testing.T) {
+	systemInfo := newTestVersionsSystemInfo(t)
+	pkg := PackageData{Source: "github", Owner: "junegunn", Repo: "fzf", Tag: "0.45.0", Asset: "fzf-0.45.0.tar.gz", Binary: "fzf"}
+	installTestVersion(t, systemInfo, pkg)
+	if err := os.WriteFile(filepath.Join(systemInfo.StewTmpPath, "leftover"), []byte("leftover"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(systemInfo.StewLockFilePath, []byte(`{"schemaVersion": 99, "packages": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	findings, err := DiagnoseInstallation(systemInfo, "linux", "amd64", systemInfo.StewBinPath)
+	if err != nil {
+		t.Fatalf("DiagnoseInstallation() error = %v", err)
+	}
+	if len(findings) != 1 || findings[0].Severity != DoctorError || !strings.Contains(findings[0].Problem, "lockfile") {
+		t.Errorf("DiagnoseInstallation() = %v, want only the unreadable lockfile", findings)
+	}
+	for _, finding := range findings {
+		if finding.CanFix() {
+			t.Errorf("DiagnoseInstallation() found a fixable problem with an unreadable lockfile: %v", finding)
+		}
+	}
+	if got := readTestBinary(t, filepath.Join(systemInfo.StewBinPath, "fzf")); got != "0.45.0" {
+		t.Errorf("The installed binary is %v, want 0.45.0", got)
+	}
+}
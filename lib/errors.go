@@ -2,6 +2,7 @@ package stew
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/marwanhawari/stew/constants"
 )
@@ -234,3 +235,312 @@ func (e InvalidGitlabSearchQueryError) Error() string {
 		constants.RedColor(e.SearchQuery),
 	)
 }
+
+// UnsupportedPlatformError occurs if the target OS/arch is not recognized
+type UnsupportedPlatformError struct {
+	OS   string
+	Arch string
+}
+
+func (e UnsupportedPlatformError) Error() string {
+	return fmt.Sprintf(
+		"%v The platform %v is not supported",
+		constants.RedColor("Error:"),
+		constants.RedColor(e.OS+"/"+e.Arch),
+	)
+}
+
+// CrossPlatformInstallError occurs if you try to install binaries for another platform into the stewBinPath
+type CrossPlatformInstallError struct {
+	OS   string
+	Arch string
+}
+
+func (e CrossPlatformInstallError) Error() string {
+	return fmt.Sprintf(
+		"%v Binaries for %v must be installed with the --bin-path flag or resolved with the --lock-only flag",
+		constants.RedColor("Error:"),
+		constants.RedColor(e.OS+"/"+e.Arch),
+	)
+}
+
+// TagNotFoundError occurs if a release with the given tag could not be found
+type TagNotFoundError struct {
+	Tag string
+}
+
+func (e TagNotFoundError) Error() string {
+	return fmt.Sprintf("%v Could not find a release with the tag %v", constants.RedColor("Error:"), constants.RedColor(e.Tag))
+}
+
+// AssetNotFoundError occurs if an asset with the given name could not be found in a release
+type AssetNotFoundError struct {
+	Tag   string
+	Asset string
+}
+
+func (e AssetNotFoundError) Error() string {
+	return fmt.Sprintf(
+		"%v Could not find the asset %v in release %v",
+		constants.RedColor("Error:"),
+		constants.RedColor(e.Asset),
+		constants.RedColor(e.Tag),
+	)
+}
+
+// FrozenLockFileError occurs if a frozen install would have to deviate from the lockfile
+type FrozenLockFileError struct {
+	Package string
+	Reason  string
+}
+
+func (e FrozenLockFileError) Error() string {
+	return fmt.Sprintf(
+		"%v Cannot install %v from the frozen lockfile: %v",
+		constants.RedColor("Error:"),
+		constants.RedColor(e.Package),
+		e.Reason,
+	)
+}
+
+// ChecksumMismatchError occurs if a downloaded asset does not match the SHA256 digest in the lockfile
+type ChecksumMismatchError struct {
+	Asset    string
+	Expected string
+	Actual   string
+}
+
+func (e ChecksumMismatchError) Error() string {
+	return fmt.Sprintf(
+		"%v The SHA256 digest of %v is %v but the lockfile expects %v",
+		constants.RedColor("Error:"),
+		constants.RedColor(e.Asset),
+		constants.RedColor(e.Actual),
+		constants.RedColor(e.Expected),
+	)
+}
+
+// StewfileParseError occurs if a line of a Stewfile could not be parsed
+type StewfileParseError struct {
+	Path string
+	Line int
+	Err  error
+}
+
+func (e StewfileParseError) Error() string {
+	location := e.Path
+	if e.Line > 0 {
+		location = fmt.Sprintf("%v:%v", e.Path, e.Line)
+	}
+	return fmt.Sprintf(
+		"%v %v: %v",
+		constants.RedColor("Error:"),
+		constants.RedColor(location),
+		e.Err,
+	)
+}
+
+func (e StewfileParseError) Unwrap() error {
+	return e.Err
+}
+
+// HistoryParseError occurs if a line of the history journal could not be parsed
+type HistoryParseError struct {
+	Path string
+	Line int
+	Err  error
+}
+
+func (e HistoryParseError) Error() string {
+	return fmt.Sprintf(
+		"%v Skipped the corrupt history entry at %v: %v",
+		constants.RedColor("Error:"),
+		constants.RedColor(fmt.Sprintf("%v:%v", e.Path, e.Line)),
+		e.Err,
+	)
+}
+
+func (e HistoryParseError) Unwrap() error {
+	return e.Err
+}
+
+// InvalidConstraintError occurs if a version constraint could not be parsed
+type InvalidConstraintError struct {
+	Constraint string
+}
+
+func (e InvalidConstraintError) Error() string {
+	return fmt.Sprintf("%v The version constraint %v is not valid", constants.RedColor("Error:"), constants.RedColor(e.Constraint))
+}
+
+// NoMatchingTagError occurs if no release tag matches the constraint and channel of a package
+type NoMatchingTagError struct {
+	Repo       string
+	Constraint string
+	Channel    string
+}
+
+func (e NoMatchingTagError) Error() string {
+	return fmt.Sprintf(
+		"%v No release of %v matches the constraint %v on the %v channel",
+		constants.RedColor("Error:"),
+		constants.RedColor(e.Repo),
+		constants.RedColor(fmt.Sprintf("%q", e.Constraint)),
+		constants.RedColor(e.Channel),
+	)
+}
+
+// AssetPatternNotMatchedError occurs if no release asset matches an asset pattern
+type AssetPatternNotMatchedError struct {
+	AssetPattern string
+}
+
+func (e AssetPatternNotMatchedError) Error() string {
+	return fmt.Sprintf("%v No release asset matches the asset pattern %v", constants.RedColor("Error:"), constants.RedColor(e.AssetPattern))
+}
+
+// ExtraFileNotFoundError occurs if an extra file pattern doesn't match any file in the downloaded asset
+type ExtraFileNotFoundError struct {
+	Pattern string
+}
+
+func (e ExtraFileNotFoundError) Error() string {
+	return fmt.Sprintf("%v No file in the asset matches the extra file pattern %v", constants.RedColor("Error:"), constants.RedColor(e.Pattern))
+}
+
+// StewfileGroupNotFoundError occurs if a selected group is not used in the Stewfile
+type StewfileGroupNotFoundError struct {
+	Group  string
+	Groups []string
+}
+
+func (e StewfileGroupNotFoundError) Error() string {
+	return fmt.Sprintf(
+		"%v The Stewfile has no group named %v. The available groups are: %v",
+		constants.RedColor("Error:"),
+		constants.RedColor(e.Group),
+		constants.RedColor(strings.Join(e.Groups, ", ")),
+	)
+}
+
+// UnsupportedLockFileSchemaError occurs if a lockfile was written by a newer version of stew
+type UnsupportedLockFileSchemaError struct {
+	Path             string
+	SchemaVersion    int
+	SupportedVersion int
+}
+
+func (e UnsupportedLockFileSchemaError) Error() string {
+	return fmt.Sprintf(
+		"%v %v uses lockfile schema version %v, but this version of stew only supports up to version %v. Please upgrade stew.",
+		constants.RedColor("Error:"),
+		constants.RedColor(e.Path),
+		constants.RedColor(e.SchemaVersion),
+		constants.RedColor(e.SupportedVersion),
+	)
+}
+
+// NoProjectEnvironmentError occurs if there is no .stew directory in the working directory or any of its parents
+type NoProjectEnvironmentError struct{}
+
+func (e NoProjectEnvironmentError) Error() string {
+	return fmt.Sprintf(
+		"%v No %v directory was found in the current directory or any of its parents. Run %v to create one.",
+		constants.RedColor("Error:"),
+		constants.RedColor(".stew"),
+		constants.GreenColor("stew env --init"),
+	)
+}
+
+// UnsupportedShellError occurs if stew env is asked for a shell it can't print a PATH addition for
+type UnsupportedShellError struct {
+	Shell string
+}
+
+func (e UnsupportedShellError) Error() string {
+	return fmt.Sprintf(
+		"%v The %v shell is not supported. Use one of sh, fish or powershell.",
+		constants.RedColor("Error:"),
+		constants.RedColor(e.Shell),
+	)
+}
+
+// VersionNotInstalledError occurs if a version of a binary is not installed side by side with the active one
+type VersionNotInstalledError struct {
+	Binary  string
+	Version string
+}
+
+func (e VersionNotInstalledError) Error() string {
+	return fmt.Sprintf(
+		"%v Version %v of the %v binary is not installed. Run %v to see the installed versions.",
+		constants.RedColor("Error:"),
+		constants.RedColor(e.Version),
+		constants.RedColor(e.Binary),
+		constants.GreenColor("stew versions "+e.Binary),
+	)
+}
+
+// PinnedVersionNotInstalledError occurs if a shim can't run the version of a binary that is pinned for the current directory
+type PinnedVersionNotInstalledError struct {
+	Binary   string
+	Version  string
+	PinnedBy string
+}
+
+func (e PinnedVersionNotInstalledError) Error() string {
+	return fmt.Sprintf(
+		"%v Version %v of the %v binary is pinned by %v but is not installed",
+		constants.RedColor("Error:"),
+		constants.RedColor(e.Version),
+		constants.RedColor(e.Binary),
+		constants.RedColor(e.PinnedBy),
+	)
+}
+
+// NoUpgradeToRollbackError occurs if there is no recorded upgrade to roll back
+type NoUpgradeToRollbackError struct {
+	Binary string
+}
+
+func (e NoUpgradeToRollbackError) Error() string {
+	if e.Binary == "" {
+		return fmt.Sprintf("%v There is no %v run to roll back", constants.RedColor("Error:"), constants.RedColor("stew upgrade --all"))
+	}
+	return fmt.Sprintf("%v There is no upgrade of the %v binary to roll back", constants.RedColor("Error:"), constants.RedColor(e.Binary))
+}
+
+// CannotRepairBinaryError occurs if stew verify --repair has nothing to reinstall a binary from
+type CannotRepairBinaryError struct {
+	Binary string
+	Reason string
+}
+
+func (e CannotRepairBinaryError) Error() string {
+	return fmt.Sprintf("%v Could not repair the %v binary: %v", constants.RedColor("Error:"), constants.RedColor(e.Binary), e.Reason)
+}
+
+// BinaryNotFoundError occurs if a binary can't be found by its path or in the PATH variable
+type BinaryNotFoundError struct {
+	Binary string
+}
+
+func (e BinaryNotFoundError) Error() string {
+	return fmt.Sprintf("%v Could not find the %v binary in the stewBinPath or your PATH", constants.RedColor("Error:"), constants.RedColor(e.Binary))
+}
+
+// BinaryAlreadyManagedError occurs if a binary that is already in the lockfile is adopted
+type BinaryAlreadyManagedError struct {
+	Binary string
+}
+
+func (e BinaryAlreadyManagedError) Error() string {
+	return fmt.Sprintf("%v The %v binary is already managed by stew", constants.RedColor("Error:"), constants.RedColor(e.Binary))
+}
+
+// AdoptFromMultipleBinariesError occurs if stew adopt --from is used with more than one binary
+type AdoptFromMultipleBinariesError struct{}
+
+func (e AdoptFromMultipleBinariesError) Error() string {
+	return fmt.Sprintf("%v The --from flag can only be used to adopt a single binary", constants.RedColor("Error:"))
+}
@@ -0,0 +1,177 @@
+package stew
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// GarbageFile is a file or directory that stew gc deletes
+type GarbageFile struct {
+	Path string
+	Size int64
+}
+
+// RetainedVersions returns the versions of each binary that stew gc keeps, which are the active version in the lockfile
+// and the versions that the rollback history can restore
+func RetainedVersions(lockFile LockFile, history RollbackHistory) map[string]map[string]bool {
+	retained := map[string]map[string]bool{}
+	retain := func(binary, version string) {
+		if binary == "" || version == "" {
+			return
+		}
+		if retained[binary] == nil {
+			retained[binary] = map[string]bool{}
+		}
+		retained[binary][version] = true
+	}
+	for _, pkg := range lockFile.Packages {
+		retain(pkg.Binary, InstalledVersionName(pkg))
+	}
+	for _, record := range history.Upgrades {
+		retain(record.Binary, record.PreviousVersion)
+		retain(record.Binary, record.Version)
+	}
+	return retained
+}
+
+// FindGarbage returns the files in the ~/.stew/pkg and ~/.stew/assets paths that are not referenced by the lockfile or
+// the rollback history, along with any files left over in the ~/.stew/tmp path. Binaries that are no longer installed,
+// version directories that were never recorded and files outside of a version directory are garbage. The other installed versions of a binary can still be pinned by a .stew-version
+// file or a Stewfile, so they are only garbage with pruneVersions unless their version is retained. Assets are garbage
+// unless they belong to the lockfile or to a version that is kept.
+func FindGarbage(systemInfo SystemInfo, lockFile LockFile, history RollbackHistory, pruneVersions bool) ([]GarbageFile, error) {
+	retained := RetainedVersions(lockFile, history)
+	referencedAssets := map[string]bool{}
+	for _, pkg := range lockFile.Packages {
+		referencedAssets[pkg.Asset] = true
+	}
+
+	entries, err := os.ReadDir(systemInfo.StewPkgPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	garbagePaths := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		binary := entry.Name()
+		versionEntries, err := os.ReadDir(filepath.Join(systemInfo.StewPkgPath, binary))
+		if err != nil {
+			return nil, err
+		}
+		for _, versionEntry := range versionEntries {
+			version := versionEntry.Name()
+			versionPath := InstalledVersionPath(systemInfo.StewPkgPath, binary, version)
+			versionPkg, versionErr := ReadInstalledVersion(systemInfo.StewPkgPath, binary, version)
+			recorded := versionErr == nil && len(retained[binary]) > 0
+			if !retained[binary][version] && (pruneVersions || !recorded) {
+				garbagePaths = append(garbagePaths, versionPath)
+				continue
+			}
+			if versionErr == nil {
+				referencedAssets[versionPkg.Asset] = true
+			}
+		}
+		if len(retained[binary]) == 0 {
+			garbagePaths = append(garbagePaths, filepath.Join(systemInfo.StewPkgPath, binary))
+		}
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			garbagePaths = append(garbagePaths, filepath.Join(systemInfo.StewPkgPath, entry.Name()))
+		}
+	}
+
+	assetEntries, err := os.ReadDir(systemInfo.StewAssetPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, assetEntry := range assetEntries {
+		if !referencedAssets[assetEntry.Name()] {
+			garbagePaths = append(garbagePaths, filepath.Join(systemInfo.StewAssetPath, assetEntry.Name()))
+		}
+	}
+
+	tmpEntries, err := os.ReadDir(systemInfo.StewTmpPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, tmpEntry := range tmpEntries {
+		garbagePaths = append(garbagePaths, filepath.Join(systemInfo.StewTmpPath, tmpEntry.Name()))
+	}
+
+	garbage := []GarbageFile{}
+	for _, garbagePath := range garbagePaths {
+		size, err := diskUsage(garbagePath)
+		if err != nil {
+			return nil, err
+		}
+		garbage = append(garbage, GarbageFile{Path: garbagePath, Size: size})
+	}
+	return removeNestedGarbage(garbage), nil
+}
+
+// removeNestedGarbage leaves out the garbage inside a directory that is garbage itself, so that it isn't counted twice
+func removeNestedGarbage(garbage []GarbageFile) []GarbageFile {
+	garbageDirs := map[string]bool{}
+	for _, garbageFile := range garbage {
+		garbageDirs[garbageFile.Path] = true
+	}
+	kept := []GarbageFile{}
+	for _, garbageFile := range garbage {
+		if garbageDirs[filepath.Dir(garbageFile.Path)] {
+			continue
+		}
+		kept = append(kept, garbageFile)
+	}
+	return kept
+}
+
+// diskUsage returns the total size of a file, or of all the files in a directory
+func diskUsage(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		fileInfo, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		size += fileInfo.Size()
+		return nil
+	})
+	return size, err
+}
+
+// DeleteCachedAsset deletes the asset that a binary was installed from in the ~/.stew/assets path
+func DeleteCachedAsset(stewAssetPath, asset string) error {
+	if asset == "" {
+		return nil
+	}
+	err := os.Remove(filepath.Join(stewAssetPath, asset))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// FormatSize formats a number of bytes for printing [Ex: 12.3 MB]
+func FormatSize(size int64) string {
+	const unit = 1000
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "kMGTPE"[exp])
+}
@@ -0,0 +1,156 @@
+package stew
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestRetainedVersions(t *testing.T) {
+	lockFile := LockFile{Packages: []PackageData{
+		{Source: "github", Tag: "0.46.0", Asset: "fzf-0.46.0.tar.gz", Binary: "fzf"},
+		{Source: "other", Asset: "kubectl", Binary: "kubectl"},
+		{Source: "github", Tag: "14.0.0", Asset: "ripgrep-14.0.0.tar.gz"},
+	}}
+	history := RollbackHistory{Upgrades: []UpgradeRecord{{Binary: "fzf", PreviousVersion: "0.45.0", Version: "0.46.0", Run: "run1"}}}
+
+	want := map[string]map[string]bool{
+		"fzf":     {"0.45.0": true, "0.46.0": true},
+		"kubectl": {"kubectl": true},
+	}
+	if got := RetainedVersions(lockFile, history); !reflect.DeepEqual(got, want) {
+		t.Errorf("RetainedVersions() = %v, want %v", got, want)
+	}
+}
+
+func TestFindGarbage(t *testing.T) {
+	systemInfo := newTestVersionsSystemInfo(t)
+	versions := []PackageData{
+		{Source: "github", Tag: "0.44.0", Asset: "fzf-0.44.0.tar.gz", Binary: "fzf"},
+		{Source: "github", Tag: "0.45.0", Asset: "fzf-0.45.0.tar.gz", Binary: "fzf"},
+		{Source: "github", Tag: "0.46.0", Asset: "fzf-0.46.0.tar.gz", Binary: "fzf"},
+		{Source: "github", Tag: "v0.24.0", Asset: "bat-v0.24.0.tar.gz", Binary: "bat"},
+	}
+	for _, pkg := range versions {
+		installTestVersion(t, systemInfo, pkg)
+		if err := os.WriteFile(filepath.Join(systemInfo.StewAssetPath, pkg.Asset), []byte("asset"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(systemInfo.StewAssetPath, "ripgrep-14.0.0.tar.gz"), []byte("aborted install"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Older versions of stew kept assets in the pkg path
+	if err := os.WriteFile(filepath.Join(systemInfo.StewPkgPath, "fzf-0.46.0.tar.gz"), []byte("asset"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lockFile := LockFile{Packages: []PackageData{versions[2]}}
+	history := RollbackHistory{Upgrades: []UpgradeRecord{{Binary: "fzf", PreviousVersion: "0.45.0", Version: "0.46.0", Run: "run1"}}}
+
+	tests := []struct {
+		name          string
+		pruneVersions bool
+		want          []string
+	}{
+		{
+			name:          "test1",
+			pruneVersions: false,
+			want: []string{
+				filepath.Join(systemInfo.StewPkgPath, "bat"),
+				filepath.Join(systemInfo.StewPkgPath, "fzf-0.46.0.tar.gz"),
+				filepath.Join(systemInfo.StewAssetPath, "bat-v0.24.0.tar.gz"),
+				filepath.Join(systemInfo.StewAssetPath, "ripgrep-14.0.0.tar.gz"),
+			},
+		},
+		{
+			name:          "test2",
+			pruneVersions: true,
+			want: []string{
+				filepath.Join(systemInfo.StewPkgPath, "bat"),
+				filepath.Join(systemInfo.StewPkgPath, "fzf-0.46.0.tar.gz"),
+				filepath.Join(systemInfo.StewAssetPath, "bat-v0.24.0.tar.gz"),
+				filepath.Join(systemInfo.StewPkgPath, "fzf", "0.44.0"),
+				filepath.Join(systemInfo.StewAssetPath, "fzf-0.44.0.tar.gz"),
+				filepath.Join(systemInfo.StewAssetPath, "ripgrep-14.0.0.tar.gz"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			garbage, err := FindGarbage(systemInfo, lockFile, history, tt.pruneVersions)
+			if err != nil {
+				t.Fatalf("FindGarbage() error = %v", err)
+			}
+			got := []string{}
+			for _, garbageFile := range garbage {
+				got = append(got, garbageFile.Path)
+			}
+			sort.Strings(got)
+			want := tt.want
+			// installTestVersion leaves the binaries it installs in the tmp path
+			for _, binary := range []string{"bat", "fzf"} {
+				want = append(want, filepath.Join(systemInfo.StewTmpPath, binary))
+			}
+			sort.Strings(want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("FindGarbage() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestDeleteCachedAsset(t *testing.T) {
+	systemInfo := newTestVersionsSystemInfo(t)
+	pkg := PackageData{Source: "other", Asset: "kubectl", Binary: "kubectl"}
+	installTestVersion(t, systemInfo, pkg)
+	assetPath := filepath.Join(systemInfo.StewAssetPath, pkg.Asset)
+	if err := os.WriteFile(assetPath, []byte("asset"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DeleteCachedAsset(systemInfo.StewAssetPath, pkg.Asset); err != nil {
+		t.Fatalf("DeleteCachedAsset() error = %v", err)
+	}
+	if assetExists, _ := PathExists(assetPath); assetExists {
+		t.Errorf("DeleteCachedAsset() didn't delete %v", assetPath)
+	}
+	if versionsExist, _ := PathExists(filepath.Join(systemInfo.StewPkgPath, "kubectl")); !versionsExist {
+		t.Errorf("DeleteCachedAsset() deleted the versions directory of a binary with the same name as its asset")
+	}
+	if err := DeleteCachedAsset(systemInfo.StewAssetPath, pkg.Asset); err != nil {
+		t.Errorf("DeleteCachedAsset() error = %v for an asset that was already deleted", err)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		name string
+		size int64
+		want string
+	}{
+		{
+			name: "test1",
+			size: 512,
+			want: "512 B",
+		},
+		{
+			name: "test2",
+			size: 1500,
+			want: "1.5 kB",
+		},
+		{
+			name: "test3",
+			size: 12_300_000,
+			want: "12.3 MB",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatSize(tt.size); got != tt.want {
+				t.Errorf("FormatSize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
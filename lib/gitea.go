@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"time"
 
 	"github.com/marwanhawari/stew/constants"
 )
@@ -20,9 +21,11 @@ type GiteaAPIResponse []GiteaRelease
 
 // GiteaRelease contains information about a Gitea release, including the associated assets
 type GiteaRelease struct {
-	TagName string       `json:"tag_name"`
-	ID      int          `json:"id"`
-	Assets  []GiteaAsset `json:"assets"`
+	TagName     string       `json:"tag_name"`
+	ID          int          `json:"id"`
+	Body        string       `json:"body"`
+	PublishedAt time.Time    `json:"published_at"`
+	Assets      []GiteaAsset `json:"assets"`
 }
 
 // GiteaAsset contains information about a specific Gitea asset
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/marwanhawari/stew/constants"
 )
@@ -21,8 +22,10 @@ type GithubAPIResponse []GithubRelease
 
 // GithubRelease contains information about a GitHub release, including the associated assets
 type GithubRelease struct {
-	TagName string        `json:"tag_name"`
-	Assets  []GithubAsset `json:"assets"`
+	TagName     string        `json:"tag_name"`
+	Body        string        `json:"body"`
+	PublishedAt time.Time     `json:"published_at"`
+	Assets      []GithubAsset `json:"assets"`
 }
 
 // GithubAsset contains information about a specific GitHub asset
@@ -31,6 +34,7 @@ type GithubAsset struct {
 	DownloadURL string `json:"browser_download_url"`
 	Size        int    `json:"size"`
 	ContentType string `json:"content_type"`
+	Digest      string `json:"digest"`
 }
 
 func readGithubJSON(jsonString string) (GithubAPIResponse, error) {
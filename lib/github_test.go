@@ -559,7 +559,7 @@ var testGithubSearchJSON, _ = getGithubSearchJSON("marwanhawari/ppath")
 
 var testGithubSearchReadJSON RepoSearch = RepoSearch{
 	Count: 1,
-	Items: []RepoSearchResult
+	Items: []RepoSearchResult{
 		{
 			FullName:    "marwanhawari/ppath",
 			Stars:       7,
@@ -572,7 +572,7 @@ var testGithubSearchReadJSON RepoSearch = RepoSearch{
 var testGithubSearch RepoSearch = RepoSearch{
 	SearchQuery: "marwanhawari/ppath",
 	Count:       1,
-	Items: []RepoSearchResult
+	Items: []RepoSearchResult{
 		{
 			FullName:    "marwanhawari/ppath",
 			Stars:       7,
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"time"
 
 	"github.com/marwanhawari/stew/constants"
 )
@@ -20,9 +21,11 @@ type GitlabAPIResponse []GitlabRelease
 
 // GitlabRelease contains information about a Gitlab release, including the associated assets
 type GitlabRelease struct {
-	TagName string      `json:"tag_name"`
-	Name    string      `json:"name"`
-	Assets  GitlabAsset `json:"assets"`
+	TagName     string      `json:"tag_name"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	ReleasedAt  time.Time   `json:"released_at"`
+	Assets      GitlabAsset `json:"assets"`
 }
 
 // GitlabAsset contains information about a specific Gitlab asset
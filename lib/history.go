@@ -0,0 +1,102 @@
+package stew
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gookit/color"
+	"github.com/marwanhawari/stew/constants"
+)
+
+// HistoryResultSuccess is the result of an operation that succeeded
+const HistoryResultSuccess = "success"
+
+// HistoryEntry is one line of the history journal
+type HistoryEntry struct {
+	Time        time.Time `json:"time"`
+	StewVersion string    `json:"stewVersion"`
+	Operation   string    `json:"operation"`
+	Binary      string    `json:"binary"`
+	NewBinary   string    `json:"newBinary,omitempty"`
+	OldTag      string    `json:"oldTag,omitempty"`
+	NewTag      string    `json:"newTag,omitempty"`
+	Asset       string    `json:"asset,omitempty"`
+	SHA256      string    `json:"sha256,omitempty"`
+	Result      string    `json:"result"`
+}
+
+// NewHistoryEntry creates a HistoryEntry for an operation on a binary. The result is a success if err is nil and the
+// error message otherwise.
+func NewHistoryEntry(operation, binary string, err error) HistoryEntry {
+	entry := HistoryEntry{
+		Time:        time.Now().UTC(),
+		StewVersion: constants.StewVersion,
+		Operation:   operation,
+		Binary:      binary,
+		Result:      HistoryResultSuccess,
+	}
+	if err != nil {
+		entry.Result = color.ClearCode(err.Error())
+	}
+	return entry
+}
+
+// HistoryPath returns the path of the history journal in the stewPath
+func HistoryPath(stewPath string) string {
+	return filepath.Join(stewPath, "history.jsonl")
+}
+
+// AppendHistory appends an entry to the history journal. Entries are never rewritten.
+func AppendHistory(stewPath string, entry HistoryEntry) error {
+	entryBytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	historyFile, err := os.OpenFile(HistoryPath(stewPath), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer historyFile.Close()
+	_, err = historyFile.Write(append(entryBytes, '\n'))
+	return err
+}
+
+// ReadHistory reads the entries of the history journal, oldest first. Entries for other binaries are left out if
+// a binary is given. Lines that can't be parsed, like one truncated by an interrupted write, are skipped and
+// returned as a HistoryParseError each.
+func ReadHistory(stewPath, binary string) ([]HistoryEntry, []error, error) {
+	historyFile, err := os.Open(HistoryPath(stewPath))
+	if os.IsNotExist(err) {
+		return []HistoryEntry{}, []error{}, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	defer historyFile.Close()
+
+	entries := []HistoryEntry{}
+	errs := []error{}
+	scanner := bufio.NewScanner(historyFile)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			errs = append(errs, HistoryParseError{Path: HistoryPath(stewPath), Line: lineNumber, Err: err})
+			continue
+		}
+		if binary == "" || entry.Binary == binary {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return entries, errs, nil
+}
@@ -0,0 +1,82 @@
+package stew
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/marwanhawari/stew/constants"
+)
+
+func TestNewHistoryEntry(t *testing.T) {
+	entry := NewHistoryEntry("upgrade", "rg", nil)
+	if entry.Result != HistoryResultSuccess || entry.StewVersion != constants.StewVersion || entry.Time.IsZero() {
+		t.Errorf("NewHistoryEntry() = %v", entry)
+	}
+
+	entry = NewHistoryEntry("upgrade", "rg", ReleasesNotFoundError{Owner: "BurntSushi", Repo: "ripgrep"})
+	if entry.Result == HistoryResultSuccess || strings.Contains(entry.Result, "\x1b[") {
+		t.Errorf("NewHistoryEntry() result = %q, want the error without colors", entry.Result)
+	}
+}
+
+func TestReadHistory(t *testing.T) {
+	tempDir := t.TempDir()
+	entries, _, err := ReadHistory(tempDir, "")
+	if err != nil {
+		t.Fatalf("ReadHistory() error = %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("ReadHistory() = %v, want no entries", entries)
+	}
+
+	install := NewHistoryEntry("install", "rg", nil)
+	install.NewTag, install.Asset, install.SHA256 = "14.0.0", "ripgrep-14.0.0.tar.gz", "abc123"
+	rename := NewHistoryEntry("rename", "fzf", nil)
+	rename.NewBinary = "fuzzy"
+	for _, entry := range []HistoryEntry{install, rename} {
+		if err := AppendHistory(tempDir, entry); err != nil {
+			t.Fatalf("AppendHistory() error = %v", err)
+		}
+	}
+
+	entries, _, err = ReadHistory(tempDir, "")
+	if err != nil {
+		t.Fatalf("ReadHistory() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Asset != install.Asset || entries[1].NewBinary != "fuzzy" {
+		t.Errorf("ReadHistory() = %v", entries)
+	}
+	if !entries[0].Time.Equal(install.Time) {
+		t.Errorf("ReadHistory() time = %v, want %v", entries[0].Time, install.Time)
+	}
+
+	entries, _, err = ReadHistory(tempDir, "rg")
+	if err != nil {
+		t.Fatalf("ReadHistory() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].Binary != "rg" {
+		t.Errorf("ReadHistory() for rg = %v", entries)
+	}
+
+	historyFile, err := os.OpenFile(HistoryPath(tempDir), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := historyFile.WriteString(`{"time":"2024-01-01T00:00:00Z","operation":"inst` + "\n"); err != nil {
+		t.Fatal(err)
+	}
+	historyFile.Close()
+	entries, errs, err := ReadHistory(tempDir, "")
+	if err != nil {
+		t.Fatalf("ReadHistory() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("ReadHistory() = %v, want the corrupt line to be skipped", entries)
+	}
+	var parseErr HistoryParseError
+	if len(errs) != 1 || !errors.As(errs[0], &parseErr) || parseErr.Line != 3 {
+		t.Errorf("ReadHistory() errs = %v, want a HistoryParseError for line 3", errs)
+	}
+}
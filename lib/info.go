@@ -0,0 +1,239 @@
+package stew
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RepoMetadata contains the details about a repository that a git host provides
+type RepoMetadata struct {
+	Description string
+	License     string
+	Homepage    string
+}
+
+// PackageInfo contains the details about a package that are shown by stew info
+type PackageInfo struct {
+	Binary           string       `json:"binary,omitempty"`
+	Source           string       `json:"source"`
+	Host             string       `json:"host,omitempty"`
+	Repo             string       `json:"repo,omitempty"`
+	Installed        bool         `json:"installed"`
+	Tag              string       `json:"tag,omitempty"`
+	Asset            string       `json:"asset,omitempty"`
+	URL              string       `json:"url,omitempty"`
+	AssetSHA256      string       `json:"assetSha256,omitempty"`
+	BinarySHA256     string       `json:"binarySha256,omitempty"`
+	InstalledAt      *time.Time   `json:"installedAt,omitempty"`
+	Description      string       `json:"description,omitempty"`
+	License          string       `json:"license,omitempty"`
+	Homepage         string       `json:"homepage,omitempty"`
+	LatestTag        string       `json:"latestTag,omitempty"`
+	InstalledRelease *ReleaseInfo `json:"installedRelease,omitempty"`
+	LatestRelease    *ReleaseInfo `json:"latestRelease,omitempty"`
+}
+
+// ReleaseInfo contains the details about a release that are shown by stew info
+type ReleaseInfo struct {
+	Tag         string     `json:"tag"`
+	PublishedAt *time.Time `json:"publishedAt,omitempty"`
+	Notes       string     `json:"notes"`
+}
+
+type githubRepoResponse struct {
+	Description string `json:"description"`
+	Homepage    string `json:"homepage"`
+	HTMLURL     string `json:"html_url"`
+	License     *struct {
+		SPDXID string `json:"spdx_id"`
+		Name   string `json:"name"`
+	} `json:"license"`
+}
+
+type gitlabProjectResponse struct {
+	Description string `json:"description"`
+	WebURL      string `json:"web_url"`
+	License     *struct {
+		Name string `json:"name"`
+	} `json:"license"`
+}
+
+type giteaRepoResponse struct {
+	Description string   `json:"description"`
+	Website     string   `json:"website"`
+	HTMLURL     string   `json:"html_url"`
+	Licenses    []string `json:"licenses"`
+}
+
+func readGithubRepoJSON(jsonString string) (RepoMetadata, error) {
+	var response githubRepoResponse
+	if err := json.Unmarshal([]byte(jsonString), &response); err != nil {
+		return RepoMetadata{}, err
+	}
+	metadata := RepoMetadata{Description: response.Description, Homepage: firstNonEmpty(response.Homepage, response.HTMLURL)}
+	if response.License != nil {
+		// GitHub uses NOASSERTION when it can't tell which license a repository uses
+		if response.License.SPDXID != "" && response.License.SPDXID != "NOASSERTION" {
+			metadata.License = response.License.SPDXID
+		} else {
+			metadata.License = response.License.Name
+		}
+	}
+	return metadata, nil
+}
+
+func readGitlabProjectJSON(jsonString string) (RepoMetadata, error) {
+	var response gitlabProjectResponse
+	if err := json.Unmarshal([]byte(jsonString), &response); err != nil {
+		return RepoMetadata{}, err
+	}
+	metadata := RepoMetadata{Description: response.Description, Homepage: response.WebURL}
+	if response.License != nil {
+		metadata.License = response.License.Name
+	}
+	return metadata, nil
+}
+
+func readGiteaRepoJSON(jsonString string) (RepoMetadata, error) {
+	var response giteaRepoResponse
+	if err := json.Unmarshal([]byte(jsonString), &response); err != nil {
+		return RepoMetadata{}, err
+	}
+	return RepoMetadata{
+		Description: response.Description,
+		License:     strings.Join(response.Licenses, ", "),
+		Homepage:    firstNonEmpty(response.Website, response.HTMLURL),
+	}, nil
+}
+
+// GetRepoMetadata gets the description, license and homepage of a package from its source
+func GetRepoMetadata(pkg PackageData) (RepoMetadata, error) {
+	switch pkg.Source {
+	case "other":
+		return RepoMetadata{}, InstalledFromURLError{Binary: pkg.Binary}
+	case "gitlab":
+		projectString := strings.Join(GetPackageGroups(pkg), "%2F") + "%2F" + pkg.Repo
+		url := fmt.Sprintf("https://%s/api/v4/projects/%s?license=true", GetPackageReference(pkg).Host, projectString)
+		response, err := getHTTPResponseBody(url, "gitlab")
+		if err != nil {
+			return RepoMetadata{}, err
+		}
+		return readGitlabProjectJSON(response)
+	case "gitea":
+		url := fmt.Sprintf("https://%s/api/v1/repos/%v/%v", pkg.Host, pkg.Owner, pkg.Repo)
+		response, err := getHTTPResponseBody(url, "gitea")
+		if err != nil {
+			return RepoMetadata{}, err
+		}
+		return readGiteaRepoJSON(response)
+	default:
+		url := fmt.Sprintf("https://api.github.com/repos/%v/%v", pkg.Owner, pkg.Repo)
+		response, err := getHTTPResponseBody(url, "github")
+		if err != nil {
+			return RepoMetadata{}, err
+		}
+		return readGithubRepoJSON(response)
+	}
+}
+
+// ResolveInfoPackage finds the package that stew info was asked about. The input can be the name of an installed
+// binary, or an owner/repo that is looked up in the lockfile and otherwise described without being installed.
+func ResolveInfoPackage(lockFile LockFile, cliInput, host, hostType string) (PackageData, bool, error) {
+	if indexInLockFile, found := FindBinaryInLockFile(lockFile, cliInput); found {
+		return lockFile.Packages[indexInLockFile], true, nil
+	}
+	if hostType == "" {
+		hostType = "github"
+	}
+	parsedInput, err := ParseCLIInput(cliInput, hostType)
+	if err != nil {
+		return PackageData{}, false, err
+	}
+	if !parsedInput.IsGithubInput {
+		return PackageData{}, false, UnrecognizedInputError{}
+	}
+	pkg := PackageData{Source: hostType, Owner: parsedInput.Owner, Repo: parsedInput.Repo, Host: host}
+	if hostType == "gitlab" {
+		pkg.Owner = strings.Join(parsedInput.Groups, "/")
+		pkg.Groups = parsedInput.Groups
+	}
+	for _, installedPkg := range lockFile.Packages {
+		if installedPkg.Source == pkg.Source && PackageHost(installedPkg) == PackageHost(pkg) &&
+			strings.EqualFold(GetPackageOwner(installedPkg)+"/"+installedPkg.Repo, pkg.Owner+"/"+pkg.Repo) {
+			return installedPkg, true, nil
+		}
+	}
+	return pkg, false, nil
+}
+
+// NewPackageInfo creates a new instance of the PackageInfo struct from the lockfile entry of a package
+func NewPackageInfo(pkg PackageData, installed bool, userOS, userArch string) PackageInfo {
+	info := PackageInfo{
+		Binary:       pkg.Binary,
+		Source:       pkg.Source,
+		Host:         PackageHost(pkg),
+		Installed:    installed,
+		Tag:          pkg.Tag,
+		Asset:        pkg.Asset,
+		URL:          pkg.URL,
+		AssetSHA256:  pkg.Platforms[PlatformKey(userOS, userArch)].SHA256,
+		BinarySHA256: pkg.BinarySHA256,
+	}
+	if pkg.Source != "other" {
+		info.Repo = GetPackageOwner(pkg) + "/" + pkg.Repo
+	}
+	return info
+}
+
+// AddRepoMetadata adds the description, license and homepage of a package to its info
+func (info *PackageInfo) AddRepoMetadata(metadata RepoMetadata) {
+	info.Description = metadata.Description
+	info.License = metadata.License
+	info.Homepage = metadata.Homepage
+}
+
+// AddReleases adds the installed and latest releases of a package to its info. The latest tag is the one that stew
+// upgrade would pick, so it respects the constraint and channel of the package.
+func (info *PackageInfo) AddReleases(pkg PackageData, releases []Release) error {
+	tags := []string{}
+	for _, release := range releases {
+		tags = append(tags, release.TagName)
+	}
+	upgradeSpec := pkg
+	upgradeSpec.Tag = ""
+	latestTag, err := SelectTag(tags, upgradeSpec)
+	if err != nil {
+		return err
+	}
+	info.LatestTag = latestTag
+	for _, release := range releases {
+		if pkg.Tag != "" && release.TagName == pkg.Tag {
+			info.InstalledRelease = NewReleaseInfo(release)
+		}
+		if release.TagName == latestTag {
+			info.LatestRelease = NewReleaseInfo(release)
+		}
+	}
+	return nil
+}
+
+// NewReleaseInfo creates a new instance of the ReleaseInfo struct
+func NewReleaseInfo(release Release) *ReleaseInfo {
+	releaseInfo := ReleaseInfo{Tag: release.TagName, Notes: strings.TrimSpace(release.Notes)}
+	if !release.PublishedAt.IsZero() {
+		publishedAt := release.PublishedAt
+		releaseInfo.PublishedAt = &publishedAt
+	}
+	return &releaseInfo
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
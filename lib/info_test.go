@@ -0,0 +1,135 @@
+package stew
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReadRepoJSON(t *testing.T) {
+	tests := []struct {
+		name       string
+		readJSON   func(string) (RepoMetadata, error)
+		jsonString string
+		want       RepoMetadata
+	}{
+		{
+			name:       "test1",
+			readJSON:   readGithubRepoJSON,
+			jsonString: `{"description":"ripgrep recursively searches directories","homepage":"","html_url":"https://github.com/BurntSushi/ripgrep","license":{"spdx_id":"Unlicense","name":"The Unlicense"}}`,
+			want:       RepoMetadata{Description: "ripgrep recursively searches directories", License: "Unlicense", Homepage: "https://github.com/BurntSushi/ripgrep"},
+		},
+		{
+			name:       "test2",
+			readJSON:   readGithubRepoJSON,
+			jsonString: `{"description":"A tool","homepage":"https://example.com","license":{"spdx_id":"NOASSERTION","name":"Other"}}`,
+			want:       RepoMetadata{Description: "A tool", License: "Other", Homepage: "https://example.com"},
+		},
+		{
+			name:       "test3",
+			readJSON:   readGitlabProjectJSON,
+			jsonString: `{"description":"GitLab CLI","web_url":"https://gitlab.com/gitlab-org/cli","license":{"key":"mit","name":"MIT License"}}`,
+			want:       RepoMetadata{Description: "GitLab CLI", License: "MIT License", Homepage: "https://gitlab.com/gitlab-org/cli"},
+		},
+		{
+			name:       "test4",
+			readJSON:   readGiteaRepoJSON,
+			jsonString: `{"description":"A tool","website":"","html_url":"https://codeberg.org/owner/repo","licenses":["MIT","Apache-2.0"]}`,
+			want:       RepoMetadata{Description: "A tool", License: "MIT, Apache-2.0", Homepage: "https://codeberg.org/owner/repo"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.readJSON(tt.jsonString)
+			if err != nil {
+				t.Fatalf("readJSON() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readJSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveInfoPackage(t *testing.T) {
+	rg := PackageData{Source: "github", Owner: "BurntSushi", Repo: "ripgrep", Tag: "14.0.0", Binary: "rg"}
+	glab := PackageData{Source: "gitlab", Owner: "gitlab-org", Groups: []string{"gitlab-org"}, Repo: "cli", Tag: "v1.36.0", Binary: "glab", Host: "gitlab.com"}
+	lockFile := LockFile{Packages: []PackageData{rg, glab}}
+
+	tests := []struct {
+		name          string
+		cliInput      string
+		hostType      string
+		want          PackageData
+		wantInstalled bool
+		wantErr       bool
+	}{
+		{
+			name:          "test1",
+			cliInput:      "rg",
+			want:          rg,
+			wantInstalled: true,
+		},
+		{
+			name:          "test2",
+			cliInput:      "burntsushi/ripgrep",
+			want:          rg,
+			wantInstalled: true,
+		},
+		{
+			name:          "test3",
+			cliInput:      "junegunn/fzf@0.45.0",
+			want:          PackageData{Source: "github", Owner: "junegunn", Repo: "fzf"},
+			wantInstalled: false,
+		},
+		{
+			name:          "test4",
+			cliInput:      "gitlab-org/cli",
+			hostType:      "gitlab",
+			want:          glab,
+			wantInstalled: true,
+		},
+		{
+			name:     "test5",
+			cliInput: "fzf",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, installed, err := ResolveInfoPackage(lockFile, tt.cliInput, "", tt.hostType)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveInfoPackage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) || installed != tt.wantInstalled {
+				t.Errorf("ResolveInfoPackage() = %v, %v, want %v, %v", got, installed, tt.want, tt.wantInstalled)
+			}
+		})
+	}
+}
+
+func TestPackageInfoAddReleases(t *testing.T) {
+	publishedAt := time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC)
+	releases := []Release{
+		{TagName: "15.0.0-rc1", Notes: "Release candidate"},
+		{TagName: "14.1.0", Notes: " Faster searches\n", PublishedAt: publishedAt},
+		{TagName: "14.0.0", Notes: "Breaking changes"},
+	}
+	pkg := PackageData{Source: "github", Owner: "BurntSushi", Repo: "ripgrep", Tag: "14.0.0", Binary: "rg", Channel: "stable"}
+	info := NewPackageInfo(pkg, true, "linux", "amd64")
+
+	if err := info.AddReleases(pkg, releases); err != nil {
+		t.Fatalf("AddReleases() error = %v", err)
+	}
+	if info.LatestTag != "14.1.0" {
+		t.Errorf("AddReleases() picked the latest tag %v, want 14.1.0", info.LatestTag)
+	}
+	wantInstalled := &ReleaseInfo{Tag: "14.0.0", Notes: "Breaking changes"}
+	if !reflect.DeepEqual(info.InstalledRelease, wantInstalled) {
+		t.Errorf("AddReleases() installed release = %v, want %v", info.InstalledRelease, wantInstalled)
+	}
+	wantLatest := &ReleaseInfo{Tag: "14.1.0", PublishedAt: &publishedAt, Notes: "Faster searches"}
+	if !reflect.DeepEqual(info.LatestRelease, wantLatest) {
+		t.Errorf("AddReleases() latest release = %v, want %v", info.LatestRelease, wantLatest)
+	}
+}
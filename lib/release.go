@@ -0,0 +1,495 @@
+package stew
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/marwanhawari/stew/constants"
+)
+
+// Release contains the information about a release that is shared by all sources
+type Release struct {
+	TagName     string
+	Notes       string
+	PublishedAt time.Time
+	Assets      []ReleaseAsset
+}
+
+// ReleaseAsset contains the information about a release asset that is shared by all sources
+type ReleaseAsset struct {
+	Name        string
+	DownloadURL string
+	Digest      string
+}
+
+// GetPackageGroups returns the GitLab groups of a package. Older lockfiles only store them joined in the Owner field.
+func GetPackageGroups(pkg PackageData) []string {
+	if len(pkg.Groups) > 0 {
+		return pkg.Groups
+	}
+	return strings.Split(pkg.Owner, "/")
+}
+
+// GetPackageOwner returns the owner of a package. GitLab entries read from a Stewfile only store their groups.
+func GetPackageOwner(pkg PackageData) string {
+	if pkg.Owner == "" {
+		return strings.Join(pkg.Groups, "/")
+	}
+	return pkg.Owner
+}
+
+// GetReleases gets the releases for a package from its source
+func GetReleases(pkg PackageData) ([]Release, error) {
+	switch pkg.Source {
+	case "other":
+		return []Release{}, InstalledFromURLError{Binary: pkg.Binary}
+	case "gitlab":
+		host := GetPackageReference(pkg).Host
+		gitlabProject, err := NewGitlabProject(host, GetPackageGroups(pkg), pkg.Repo)
+		if err != nil {
+			return []Release{}, err
+		}
+		if _, err := GetGitlabReleasesTags(gitlabProject, host); err != nil {
+			return []Release{}, err
+		}
+		return GitlabProjectReleases(gitlabProject), nil
+	case "gitea":
+		giteaProject, err := NewGiteaProject(pkg.Host, pkg.Owner, pkg.Repo)
+		if err != nil {
+			return []Release{}, err
+		}
+		if _, err := GetGiteaReleasesTags(giteaProject); err != nil {
+			return []Release{}, err
+		}
+		return GiteaProjectReleases(giteaProject), nil
+	default:
+		githubProject, err := NewGithubProject(pkg.Owner, pkg.Repo)
+		if err != nil {
+			return []Release{}, err
+		}
+		if _, err := GetGithubReleasesTags(githubProject); err != nil {
+			return []Release{}, err
+		}
+		return GithubProjectReleases(githubProject), nil
+	}
+}
+
+// GithubProjectReleases converts the releases of a GithubProject to the releases shared by all sources
+func GithubProjectReleases(ghProject GithubProject) []Release {
+	releases := []Release{}
+	for _, githubRelease := range ghProject.Releases {
+		release := Release{TagName: githubRelease.TagName, Notes: githubRelease.Body, PublishedAt: githubRelease.PublishedAt}
+		for _, asset := range githubRelease.Assets {
+			release.Assets = append(
+				release.Assets,
+				ReleaseAsset{Name: asset.Name, DownloadURL: asset.DownloadURL, Digest: asset.Digest},
+			)
+		}
+		releases = append(releases, release)
+	}
+	return releases
+}
+
+// GitlabProjectReleases converts the releases of a GitlabProject to the releases shared by all sources
+func GitlabProjectReleases(gitlabProject GitlabProject) []Release {
+	releases := []Release{}
+	for _, gitlabRelease := range gitlabProject.Releases {
+		release := Release{TagName: gitlabRelease.TagName, Notes: gitlabRelease.Description, PublishedAt: gitlabRelease.ReleasedAt}
+		for _, link := range gitlabRelease.Assets.Links {
+			release.Assets = append(release.Assets, ReleaseAsset{Name: link.Name, DownloadURL: link.DownloadURL})
+		}
+		releases = append(releases, release)
+	}
+	return releases
+}
+
+// GiteaProjectReleases converts the releases of a GiteaProject to the releases shared by all sources
+func GiteaProjectReleases(giteaProject GiteaProject) []Release {
+	releases := []Release{}
+	for _, giteaRelease := range giteaProject.Releases {
+		release := Release{TagName: giteaRelease.TagName, Notes: giteaRelease.Body, PublishedAt: giteaRelease.PublishedAt}
+		for _, asset := range giteaRelease.Assets {
+			release.Assets = append(release.Assets, ReleaseAsset{Name: asset.Name, DownloadURL: asset.DownloadURL})
+		}
+		releases = append(releases, release)
+	}
+	return releases
+}
+
+// FindRelease finds the release with the given tag. An empty tag or "latest" will return the latest release.
+func FindRelease(releases []Release, tag string) (Release, error) {
+	if len(releases) == 0 {
+		return Release{}, AssetsNotFoundError{Tag: tag}
+	}
+	if tag == "" || tag == "latest" {
+		return releases[0], nil
+	}
+	for _, release := range releases {
+		if release.TagName == tag {
+			return release, nil
+		}
+	}
+	return Release{}, TagNotFoundError{Tag: tag}
+}
+
+// GetReleaseAssetNames gets a string slice of the asset names for a release
+func GetReleaseAssetNames(release Release) []string {
+	assetNames := []string{}
+	for _, asset := range release.Assets {
+		assetNames = append(assetNames, asset.Name)
+	}
+	return assetNames
+}
+
+// FindReleaseAsset finds the asset with the given name in a release
+func FindReleaseAsset(release Release, assetName string) (ReleaseAsset, error) {
+	for _, asset := range release.Assets {
+		if asset.Name == assetName {
+			return asset, nil
+		}
+	}
+	return ReleaseAsset{}, AssetNotFoundError{Tag: release.TagName, Asset: assetName}
+}
+
+// GetAssetSHA256 returns the SHA256 digest of a release asset. It uses the digest reported by the source when
+// available, otherwise the asset is downloaded into the stew tmp path and hashed.
+func GetAssetSHA256(asset ReleaseAsset, source string, stewTmpPath string) (string, error) {
+	if digest, found := strings.CutPrefix(asset.Digest, "sha256:"); found {
+		return digest, nil
+	}
+	err := os.MkdirAll(stewTmpPath, 0755)
+	if err != nil {
+		return "", err
+	}
+	downloadPath := filepath.Join(stewTmpPath, asset.Name)
+	defer os.RemoveAll(downloadPath)
+	err = DownloadFile(downloadPath, asset.DownloadURL, source)
+	if err != nil {
+		return "", err
+	}
+	return SHA256File(downloadPath)
+}
+
+// LockPackagePlatforms resolves the release asset of a package for every platform and records them in pkg.Platforms.
+// The lockfile's own platform reuses the asset that is already installed.
+func LockPackagePlatforms(pkg PackageData, lockFile LockFile, platforms []string, stewTmpPath string) (PackageData, error) {
+	if pkg.Source == "other" {
+		return pkg, InstalledFromURLError{Binary: pkg.Binary}
+	}
+	releases, err := GetReleases(pkg)
+	if err != nil {
+		return PackageData{}, err
+	}
+	tag := pkg.Tag
+	if (tag == "" || tag == "latest") && len(releases) > 0 {
+		releaseTags := []string{}
+		for _, release := range releases {
+			releaseTags = append(releaseTags, release.TagName)
+		}
+		tag, err = SelectTag(releaseTags, pkg)
+		if err != nil {
+			return PackageData{}, err
+		}
+	}
+	release, err := FindRelease(releases, tag)
+	if err != nil {
+		return PackageData{}, err
+	}
+	pkg.Tag = release.TagName
+
+	// The asset of a package only applies to the platform it was installed on, other platforms are selected again
+	selectionPkg := pkg
+	selectionPkg.Asset = ""
+
+	lockedPlatforms := make(map[string]PlatformData, len(platforms))
+	for _, platform := range platforms {
+		platformOS, platformArch, err := ParsePlatform(platform)
+		if err != nil {
+			return PackageData{}, err
+		}
+		existing, existingFound := pkg.Platforms[platform]
+
+		_, hasOverride := pkg.Overrides[platform]
+
+		var assetName string
+		if hasOverride {
+			assetName, err = SelectAsset(GetReleaseAssetNames(release), selectionPkg, platformOS, platformArch)
+			if err != nil {
+				return PackageData{}, err
+			}
+		} else if platformOS == lockFile.Os && platformArch == lockFile.Arch && pkg.Asset != "" {
+			assetName = pkg.Asset
+		} else if _, err := FindReleaseAsset(release, existing.Asset); existingFound && err == nil {
+			assetName = existing.Asset
+		} else {
+			fmt.Printf("🔍 Resolving %v for %v\n", constants.GreenColor(pkg.Owner+"/"+pkg.Repo), constants.GreenColor(platform))
+			assetName, err = SelectAsset(GetReleaseAssetNames(release), selectionPkg, platformOS, platformArch)
+			if err != nil {
+				return PackageData{}, err
+			}
+		}
+		asset, err := FindReleaseAsset(release, assetName)
+		if err != nil {
+			return PackageData{}, err
+		}
+
+		// Entries that already point at the same asset don't need to be hashed again
+		if existingFound && existing.URL == asset.DownloadURL && existing.SHA256 != "" {
+			lockedPlatforms[platform] = existing
+			continue
+		}
+		sha256, err := GetAssetSHA256(asset, pkg.Source, stewTmpPath)
+		if err != nil {
+			return PackageData{}, err
+		}
+		lockedPlatforms[platform] = PlatformData{Asset: asset.Name, URL: asset.DownloadURL, SHA256: sha256}
+	}
+	pkg.Platforms = lockedPlatforms
+
+	return pkg, nil
+}
+
+// LockStewfilePackage resolves a Stewfile entry into a lockfile entry without installing it. The previously locked
+// entry for the same package, if any, is used to avoid hashing assets that haven't changed.
+func LockStewfilePackage(
+	pkg PackageData,
+	previousPkg PackageData,
+	lockFile LockFile,
+	platforms []string,
+	stewTmpPath string,
+) (PackageData, error) {
+	if pkg.Binary == "" {
+		pkg.Binary = previousPkg.Binary
+	}
+	hostPlatform := PlatformKey(lockFile.Os, lockFile.Arch)
+
+	if pkg.Source == "other" {
+		pkg.Asset = filepath.Base(pkg.URL)
+		// A URL only works on the platform it was built for, so it is locked for the host platform unless the
+		// entry is restricted to specific platforms
+		urlPlatforms := []string{hostPlatform}
+		if len(pkg.OnlyOS) > 0 || len(pkg.OnlyArch) > 0 {
+			urlPlatforms = platforms
+		}
+		pkg.Platforms = map[string]PlatformData{}
+		var platformData PlatformData
+		for _, platform := range urlPlatforms {
+			if platformData.SHA256 == "" {
+				previousPlatformData, found := previousPkg.Platforms[platform]
+				if found && previousPlatformData.URL == pkg.URL && previousPlatformData.SHA256 != "" {
+					platformData = previousPlatformData
+				} else {
+					sha256, err := GetAssetSHA256(ReleaseAsset{Name: pkg.Asset, DownloadURL: pkg.URL}, pkg.Source, stewTmpPath)
+					if err != nil {
+						return PackageData{}, err
+					}
+					platformData = PlatformData{Asset: pkg.Asset, URL: pkg.URL, SHA256: sha256}
+				}
+			}
+			pkg.Platforms[platform] = platformData
+		}
+		return pkg, nil
+	}
+
+	pkg.Platforms = previousPkg.Platforms
+	lockedPkg, err := LockPackagePlatforms(pkg, lockFile, platforms, stewTmpPath)
+	if err != nil {
+		return PackageData{}, err
+	}
+	if platformData, found := lockedPkg.Platforms[hostPlatform]; found {
+		lockedPkg.Asset = platformData.Asset
+		lockedPkg.URL = platformData.URL
+	}
+	return lockedPkg, nil
+}
+
+// CompareTags compares two release tags by their dot separated numeric parts [Ex: v1.10.0 > v1.9.2].
+// A pre-release suffix sorts before the release itself [Ex: v1.2.0-rc1 < v1.2.0].
+// It returns -1, 0, or 1 and falls back to comparing the raw strings for tags that aren't numeric.
+func CompareTags(a, b string) int {
+	aParts, aPreRelease, aOk := splitTag(a)
+	bParts, bPreRelease, bOk := splitTag(b)
+	if !aOk || !bOk {
+		return strings.Compare(a, b)
+	}
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		aNumber, bNumber := 0, 0
+		if i < len(aParts) {
+			aNumber = aParts[i]
+		}
+		if i < len(bParts) {
+			bNumber = bParts[i]
+		}
+		if aNumber != bNumber {
+			if aNumber < bNumber {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case aPreRelease == bPreRelease:
+		return 0
+	case aPreRelease == "":
+		return 1
+	case bPreRelease == "":
+		return -1
+	}
+	return strings.Compare(aPreRelease, bPreRelease)
+}
+
+// splitTag splits a tag into its numeric version parts and pre-release suffix, ignoring any prefix before the first digit
+func splitTag(tag string) ([]int, string, bool) {
+	start := strings.IndexFunc(tag, unicode.IsDigit)
+	if start == -1 {
+		return nil, "", false
+	}
+	version, _, _ := strings.Cut(tag[start:], "+")
+	version, preRelease, _ := strings.Cut(version, "-")
+	parts := []int{}
+	for _, part := range strings.Split(version, ".") {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, "", false
+		}
+		parts = append(parts, number)
+	}
+	return parts, preRelease, true
+}
+
+var preReleaseTagRegex = regexp.MustCompile(`(?i)(alpha|beta|rc\d*$|preview|nightly|snapshot)`)
+
+// IsPreReleaseTag checks if a tag looks like a pre-release [Ex: v1.2.0-rc1, nightly]
+func IsPreReleaseTag(tag string) bool {
+	_, preRelease, ok := splitTag(tag)
+	if ok && preRelease != "" {
+		return true
+	}
+	return preReleaseTagRegex.MatchString(tag)
+}
+
+// SatisfiesConstraint checks if a tag satisfies a version constraint.
+// A constraint is a comma separated list of clauses that must all match [Ex: >=1.2, <2.0].
+// Supported operators are >=, >, <=, <, = and !=, and a trailing wildcard matches a prefix [Ex: 1.4.*].
+func SatisfiesConstraint(tag, constraint string) (bool, error) {
+	for _, clause := range strings.Split(constraint, ",") {
+		clause = strings.TrimSpace(clause)
+		operator := "="
+		for _, candidate := range []string{">=", "<=", "!=", ">", "<", "="} {
+			if strings.HasPrefix(clause, candidate) {
+				operator = candidate
+				clause = strings.TrimSpace(strings.TrimPrefix(clause, candidate))
+				break
+			}
+		}
+		if clause == "" {
+			return false, InvalidConstraintError{Constraint: constraint}
+		}
+
+		if strings.HasSuffix(clause, "*") {
+			if operator != "=" && operator != "!=" {
+				return false, InvalidConstraintError{Constraint: constraint}
+			}
+			prefix := strings.TrimSuffix(clause, "*")
+			matches := strings.HasPrefix(tag, prefix)
+			if start := strings.IndexFunc(tag, unicode.IsDigit); start != -1 {
+				matches = matches || strings.HasPrefix(tag[start:], prefix)
+			}
+			if matches != (operator == "=") {
+				return false, nil
+			}
+			continue
+		}
+		if _, _, ok := splitTag(clause); !ok {
+			return false, InvalidConstraintError{Constraint: constraint}
+		}
+
+		comparison := CompareTags(tag, clause)
+		var matches bool
+		switch operator {
+		case ">=":
+			matches = comparison >= 0
+		case "<=":
+			matches = comparison <= 0
+		case ">":
+			matches = comparison > 0
+		case "<":
+			matches = comparison < 0
+		case "=":
+			matches = comparison == 0
+		case "!=":
+			matches = comparison != 0
+		}
+		if !matches {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// SelectTag picks the tag to install for a package from the release tags, which are ordered newest first.
+// A pinned tag is used as is. Otherwise the newest tag matching the constraint and channel of the package is picked.
+func SelectTag(releaseTags []string, pkg PackageData) (string, error) {
+	if pkg.Tag != "" && pkg.Tag != "latest" {
+		return pkg.Tag, nil
+	}
+	selectedTag := ""
+	for _, tag := range releaseTags {
+		if pkg.Channel == "stable" && IsPreReleaseTag(tag) {
+			continue
+		}
+		if pkg.Constraint == "" {
+			return tag, nil
+		}
+		matches, err := SatisfiesConstraint(tag, pkg.Constraint)
+		if err != nil {
+			return "", err
+		}
+		if matches && (selectedTag == "" || CompareTags(tag, selectedTag) > 0) {
+			selectedTag = tag
+		}
+	}
+	if selectedTag == "" {
+		return "", NoMatchingTagError{Repo: pkg.Repo, Constraint: pkg.Constraint, Channel: pkg.Channel}
+	}
+	return selectedTag, nil
+}
+
+// SelectAsset picks the asset to install for a package on a platform.
+// Platform overrides take precedence over the asset and asset pattern of the package, and the asset is detected if neither is set.
+func SelectAsset(releaseAssets []string, pkg PackageData, targetOS, targetArch string) (string, error) {
+	asset, assetPattern := pkg.Asset, pkg.AssetPattern
+	if override, found := pkg.Overrides[PlatformKey(targetOS, targetArch)]; found {
+		asset, assetPattern = override.Asset, override.AssetPattern
+	}
+	if asset != "" {
+		return asset, nil
+	}
+	if assetPattern == "" {
+		return DetectAsset(targetOS, targetArch, releaseAssets)
+	}
+
+	assetRegex, err := regexp.Compile(assetPattern)
+	if err != nil {
+		return "", err
+	}
+	matches := []string{}
+	for _, releaseAsset := range releaseAssets {
+		if assetRegex.MatchString(releaseAsset) {
+			matches = append(matches, releaseAsset)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", AssetPatternNotMatchedError{AssetPattern: assetPattern}
+	case 1:
+		return matches[0], nil
+	}
+	return WarningPromptSelect("Multiple assets match the asset pattern. Select an asset:", matches)
+}
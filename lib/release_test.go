@@ -0,0 +1,413 @@
+package stew
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testSourceReleases []Release = []Release{
+	{
+		TagName: "v0.0.3",
+		Assets: []ReleaseAsset{
+			{
+				Name:        "ppath-v0.0.3-darwin-arm64.tar.gz",
+				DownloadURL: "https://github.com/marwanhawari/ppath/releases/download/v0.0.3/ppath-v0.0.3-darwin-arm64.tar.gz",
+				Digest:      "sha256:1f2b4c1a4b0a8f1f1d6a6f6c3e1f7c9d0e2b8a4c6d8e0f2a4c6e8a0b2c4d6e8f",
+			},
+			{
+				Name:        "ppath-v0.0.3-linux-amd64.tar.gz",
+				DownloadURL: "https://github.com/marwanhawari/ppath/releases/download/v0.0.3/ppath-v0.0.3-linux-amd64.tar.gz",
+			},
+		},
+	},
+	{
+		TagName: "v0.0.2",
+		Assets: []ReleaseAsset{
+			{
+				Name:        "ppath-v0.0.2-darwin-arm64.tar.gz",
+				DownloadURL: "https://github.com/marwanhawari/ppath/releases/download/v0.0.2/ppath-v0.0.2-darwin-arm64.tar.gz",
+			},
+		},
+	},
+}
+
+func TestFindRelease(t *testing.T) {
+	tests := []struct {
+		name    string
+		tag     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "test1",
+			tag:     "",
+			want:    "v0.0.3",
+			wantErr: false,
+		},
+		{
+			name:    "test2",
+			tag:     "v0.0.2",
+			want:    "v0.0.2",
+			wantErr: false,
+		},
+		{
+			name:    "test3",
+			tag:     "v9.9.9",
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindRelease(testSourceReleases, tt.tag)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FindRelease() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got.TagName != tt.want {
+				t.Errorf("FindRelease() = %v, want %v", got.TagName, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindReleaseAsset(t *testing.T) {
+	got, err := FindReleaseAsset(testSourceReleases[0], "ppath-v0.0.3-linux-amd64.tar.gz")
+	if err != nil {
+		t.Fatalf("FindReleaseAsset() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, testSourceReleases[0].Assets[1]) {
+		t.Errorf("FindReleaseAsset() = %v, want %v", got, testSourceReleases[0].Assets[1])
+	}
+
+	_, err = FindReleaseAsset(testSourceReleases[0], "ppath-v0.0.3-windows-amd64.zip")
+	if err == nil {
+		t.Errorf("FindReleaseAsset() expected an error for a missing asset")
+	}
+}
+
+func TestGetAssetSHA256(t *testing.T) {
+	got, err := GetAssetSHA256(testSourceReleases[0].Assets[0], "github", t.TempDir())
+	if err != nil {
+		t.Fatalf("GetAssetSHA256() error = %v", err)
+	}
+	want := "1f2b4c1a4b0a8f1f1d6a6f6c3e1f7c9d0e2b8a4c6d8e0f2a4c6e8a0b2c4d6e8f"
+	if got != want {
+		t.Errorf("GetAssetSHA256() = %v, want %v", got, want)
+	}
+}
+
+func TestGetPackageGroups(t *testing.T) {
+	tests := []struct {
+		name string
+		pkg  PackageData
+		want []string
+	}{
+		{
+			name: "test1",
+			pkg:  PackageData{Owner: "group/subgroup", Repo: "project"},
+			want: []string{"group", "subgroup"},
+		},
+		{
+			name: "test2",
+			pkg:  PackageData{Groups: []string{"group", "subgroup"}, Repo: "project"},
+			want: []string{"group", "subgroup"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetPackageGroups(tt.pkg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetPackageGroups() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLockStewfilePackage(t *testing.T) {
+	hyperfineURL := "https://github.com/sharkdp/hyperfine/releases/download/v1.12.0/hyperfine-v1.12.0-x86_64-apple-darwin.tar.gz"
+	previousPkg := PackageData{
+		Source: "other",
+		Asset:  "hyperfine-v1.12.0-x86_64-apple-darwin.tar.gz",
+		Binary: "hyperfine",
+		URL:    hyperfineURL,
+		Platforms: map[string]PlatformData{
+			"darwin/arm64": {
+				Asset:  "hyperfine-v1.12.0-x86_64-apple-darwin.tar.gz",
+				URL:    hyperfineURL,
+				SHA256: "f14515efd10b46142f50cb68fe727fa7c65dfa2e7b2d5f2212f4f417c2e9828d",
+			},
+		},
+	}
+	lockFile := LockFile{Os: "darwin", Arch: "arm64"}
+
+	got, err := LockStewfilePackage(PackageData{Source: "other", URL: hyperfineURL}, previousPkg, lockFile, []string{"darwin/arm64"}, t.TempDir())
+	if err != nil {
+		t.Fatalf("LockStewfilePackage() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, previousPkg) {
+		t.Errorf("LockStewfilePackage() = %v, want %v", got, previousPkg)
+	}
+}
+
+func TestCompareTags(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want int
+	}{
+		{
+			name: "test1",
+			a:    "v1.10.0",
+			b:    "v1.9.2",
+			want: 1,
+		},
+		{
+			name: "test2",
+			a:    "0.29.0",
+			b:    "0.29.0",
+			want: 0,
+		},
+		{
+			name: "test3",
+			a:    "v1.2",
+			b:    "v1.2.1",
+			want: -1,
+		},
+		{
+			name: "test4",
+			a:    "nightly",
+			b:    "stable",
+			want: -1,
+		},
+		{
+			name: "test5",
+			a:    "v1.2.0-rc1",
+			b:    "v1.2.0",
+			want: -1,
+		},
+		{
+			name: "test6",
+			a:    "jq-1.7.1",
+			b:    "jq-1.6",
+			want: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CompareTags(tt.a, tt.b); got != tt.want {
+				t.Errorf("CompareTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPreReleaseTag(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  string
+		want bool
+	}{
+		{
+			name: "test1",
+			tag:  "v1.2.0",
+			want: false,
+		},
+		{
+			name: "test2",
+			tag:  "v1.2.0-rc1",
+			want: true,
+		},
+		{
+			name: "test3",
+			tag:  "nightly",
+			want: true,
+		},
+		{
+			name: "test4",
+			tag:  "2.0.0beta",
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsPreReleaseTag(tt.tag); got != tt.want {
+				t.Errorf("IsPreReleaseTag() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSatisfiesConstraint(t *testing.T) {
+	tests := []struct {
+		name       string
+		tag        string
+		constraint string
+		want       bool
+		wantErr    bool
+	}{
+		{
+			name:       "test1",
+			tag:        "v1.4.2",
+			constraint: ">=1.2, <2.0",
+			want:       true,
+		},
+		{
+			name:       "test2",
+			tag:        "v2.0.0",
+			constraint: ">=1.2, <2.0",
+			want:       false,
+		},
+		{
+			name:       "test3",
+			tag:        "v1.4.2",
+			constraint: "1.4.*",
+			want:       true,
+		},
+		{
+			name:       "test4",
+			tag:        "v1.40.0",
+			constraint: "1.4.*",
+			want:       false,
+		},
+		{
+			name:       "test5",
+			tag:        "0.29.0",
+			constraint: "!=0.29.0",
+			want:       false,
+		},
+		{
+			name:       "test6",
+			tag:        "0.29.0",
+			constraint: ">=",
+			wantErr:    true,
+		},
+		{
+			name:       "test7",
+			tag:        "0.29.0",
+			constraint: ">latest",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SatisfiesConstraint(tt.tag, tt.constraint)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SatisfiesConstraint() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("SatisfiesConstraint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectTag(t *testing.T) {
+	releaseTags := []string{"v2.1.0-rc1", "v2.0.0", "v1.9.0", "v1.10.0"}
+	tests := []struct {
+		name    string
+		pkg     PackageData
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "test1",
+			pkg:  PackageData{Repo: "fzf"},
+			want: "v2.1.0-rc1",
+		},
+		{
+			name: "test2",
+			pkg:  PackageData{Repo: "fzf", Channel: "stable"},
+			want: "v2.0.0",
+		},
+		{
+			name: "test3",
+			pkg:  PackageData{Repo: "fzf", Channel: "stable", Constraint: "<2"},
+			want: "v1.10.0",
+		},
+		{
+			name: "test4",
+			pkg:  PackageData{Repo: "fzf", Tag: "v1.9.0", Constraint: "<2"},
+			want: "v1.9.0",
+		},
+		{
+			name:    "test5",
+			pkg:     PackageData{Repo: "fzf", Channel: "stable", Constraint: ">=3"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SelectTag(releaseTags, tt.pkg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SelectTag() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("SelectTag() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectAsset(t *testing.T) {
+	releaseAssets := []string{
+		"fzf-0.29.0-darwin_amd64.zip",
+		"fzf-0.29.0-linux_amd64.tar.gz",
+		"fzf-0.29.0-linux_arm64.tar.gz",
+		"fzf-0.29.0-linux_amd64-musl.tar.gz",
+	}
+	tests := []struct {
+		name       string
+		pkg        PackageData
+		targetOS   string
+		targetArch string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "test1",
+			pkg:        PackageData{},
+			targetOS:   "linux",
+			targetArch: "arm64",
+			want:       "fzf-0.29.0-linux_arm64.tar.gz",
+		},
+		{
+			name:       "test2",
+			pkg:        PackageData{AssetPattern: `musl\.tar\.gz$`},
+			targetOS:   "linux",
+			targetArch: "amd64",
+			want:       "fzf-0.29.0-linux_amd64-musl.tar.gz",
+		},
+		{
+			name: "test3",
+			pkg: PackageData{
+				AssetPattern: `musl\.tar\.gz$`,
+				Overrides:    map[string]PlatformOverride{"darwin/amd64": {Asset: "fzf-0.29.0-darwin_amd64.zip"}},
+			},
+			targetOS:   "darwin",
+			targetArch: "amd64",
+			want:       "fzf-0.29.0-darwin_amd64.zip",
+		},
+		{
+			name:       "test4",
+			pkg:        PackageData{AssetPattern: `windows`},
+			targetOS:   "linux",
+			targetArch: "amd64",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SelectAsset(releaseAssets, tt.pkg, tt.targetOS, tt.targetArch)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SelectAsset() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("SelectAsset() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package stew
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// RollbackDepth is the number of upgrades of each binary that can be rolled back
+const RollbackDepth = 5
+
+// UpgradeRecord records an upgrade of a binary so that it can be rolled back
+type UpgradeRecord struct {
+	Binary          string `json:"binary"`
+	PreviousVersion string `json:"previousVersion"`
+	Version         string `json:"version"`
+	Run             string `json:"run"`
+	All             bool   `json:"all,omitempty"`
+}
+
+// RollbackHistory contains the upgrades that can be rolled back, oldest first
+type RollbackHistory struct {
+	Upgrades []UpgradeRecord `json:"upgrades"`
+}
+
+// NewUpgradeRun returns an identifier for the upgrades that are done by one run of stew upgrade
+func NewUpgradeRun() string {
+	return time.Now().UTC().Format(time.RFC3339Nano)
+}
+
+func rollbackHistoryPath(stewPath string) string {
+	return filepath.Join(stewPath, "rollback.json")
+}
+
+// ReadRollbackHistory reads the upgrades that can be rolled back. It returns an empty history if nothing was upgraded yet.
+func ReadRollbackHistory(stewPath string) (RollbackHistory, error) {
+	historyBytes, err := os.ReadFile(rollbackHistoryPath(stewPath))
+	if os.IsNotExist(err) {
+		return RollbackHistory{Upgrades: []UpgradeRecord{}}, nil
+	}
+	if err != nil {
+		return RollbackHistory{}, err
+	}
+	var history RollbackHistory
+	if err := json.Unmarshal(historyBytes, &history); err != nil {
+		return RollbackHistory{}, err
+	}
+	return history, nil
+}
+
+// WriteRollbackHistory writes the upgrades that can be rolled back
+func WriteRollbackHistory(stewPath string, history RollbackHistory) error {
+	historyBytes, err := json.MarshalIndent(history, "", "\t")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(rollbackHistoryPath(stewPath), append(historyBytes, '\n'), 0644)
+}
+
+// AddUpgradeRecord adds an upgrade to the history and forgets the oldest upgrades of the binary beyond the RollbackDepth
+func AddUpgradeRecord(history RollbackHistory, record UpgradeRecord) RollbackHistory {
+	upgrades := append(history.Upgrades, record)
+	count := 0
+	kept := []UpgradeRecord{}
+	for i := len(upgrades) - 1; i >= 0; i-- {
+		if upgrades[i].Binary == record.Binary {
+			count++
+			if count > RollbackDepth {
+				continue
+			}
+		}
+		kept = append([]UpgradeRecord{upgrades[i]}, kept...)
+	}
+	return RollbackHistory{Upgrades: kept}
+}
+
+// PopUpgradeRecord removes the most recent upgrade of a binary from the history
+func PopUpgradeRecord(history RollbackHistory, binary string) (RollbackHistory, UpgradeRecord, bool) {
+	for i := len(history.Upgrades) - 1; i >= 0; i-- {
+		if history.Upgrades[i].Binary == binary {
+			record := history.Upgrades[i]
+			upgrades := append(append([]UpgradeRecord{}, history.Upgrades[:i]...), history.Upgrades[i+1:]...)
+			return RollbackHistory{Upgrades: upgrades}, record, true
+		}
+	}
+	return history, UpgradeRecord{}, false
+}
+
+// PopUpgradeAllRun removes the upgrades of the most recent run of stew upgrade --all from the history
+func PopUpgradeAllRun(history RollbackHistory) (RollbackHistory, []UpgradeRecord, bool) {
+	run := ""
+	for i := len(history.Upgrades) - 1; i >= 0; i-- {
+		if history.Upgrades[i].All {
+			run = history.Upgrades[i].Run
+			break
+		}
+	}
+	if run == "" {
+		return history, nil, false
+	}
+	upgrades := []UpgradeRecord{}
+	records := []UpgradeRecord{}
+	for _, record := range history.Upgrades {
+		if record.Run == run {
+			records = append(records, record)
+		} else {
+			upgrades = append(upgrades, record)
+		}
+	}
+	return RollbackHistory{Upgrades: upgrades}, records, true
+}
+
+// RetainInstalledVersion moves a binary that was installed before versions were kept side by side into its own
+// version directory, so that it is kept when the binary is upgraded
+func RetainInstalledVersion(systemInfo SystemInfo, pkg PackageData) error {
+	versionPath := InstalledVersionPath(systemInfo.StewPkgPath, pkg.Binary, InstalledVersionName(pkg))
+	versionInstalled, err := PathExists(versionPath)
+	if err != nil || versionInstalled {
+		return err
+	}
+	binaryPath := filepath.Join(systemInfo.StewBinPath, pkg.Binary)
+	if fileInfo, err := os.Lstat(binaryPath); err != nil || !fileInfo.Mode().IsRegular() {
+		return nil
+	}
+
+	extraFiles := []string{}
+	for _, extraFile := range pkg.InstalledExtraFiles {
+		extraFiles = append(extraFiles, filepath.Join(systemInfo.StewBinPath, extraFile))
+	}
+	tmpBinaryPath := filepath.Join(systemInfo.StewTmpPath, pkg.Binary)
+	if err := os.MkdirAll(systemInfo.StewTmpPath, 0755); err != nil {
+		return err
+	}
+	if err := copyFile(binaryPath, tmpBinaryPath); err != nil {
+		return err
+	}
+	defer os.Remove(tmpBinaryPath)
+	if _, err := installVersion(systemInfo, pkg.Binary, InstalledVersionName(pkg), tmpBinaryPath, extraFiles); err != nil {
+		return err
+	}
+	return RecordInstalledVersion(systemInfo.StewPkgPath, pkg)
+}
@@ -0,0 +1,124 @@
+package stew
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAddUpgradeRecord(t *testing.T) {
+	history := RollbackHistory{Upgrades: []UpgradeRecord{{Binary: "rg", PreviousVersion: "13.0.0", Version: "14.0.0", Run: "run0"}}}
+	for i := 0; i < RollbackDepth+2; i++ {
+		history = AddUpgradeRecord(history, UpgradeRecord{Binary: "fzf", PreviousVersion: string(rune('a' + i)), Version: string(rune('b' + i)), Run: "run"})
+	}
+
+	fzfRecords := []UpgradeRecord{}
+	for _, record := range history.Upgrades {
+		if record.Binary == "fzf" {
+			fzfRecords = append(fzfRecords, record)
+		}
+	}
+	if len(fzfRecords) != RollbackDepth {
+		t.Fatalf("AddUpgradeRecord() kept %v upgrades of fzf, want %v", len(fzfRecords), RollbackDepth)
+	}
+	if fzfRecords[0].PreviousVersion != "c" {
+		t.Errorf("AddUpgradeRecord() oldest kept upgrade is from %v, want c", fzfRecords[0].PreviousVersion)
+	}
+	if history.Upgrades[0].Binary != "rg" {
+		t.Errorf("AddUpgradeRecord() removed the upgrades of another binary")
+	}
+}
+
+func TestPopUpgradeRecord(t *testing.T) {
+	history := RollbackHistory{Upgrades: []UpgradeRecord{
+		{Binary: "fzf", PreviousVersion: "0.44.0", Version: "0.45.0", Run: "run1"},
+		{Binary: "rg", PreviousVersion: "13.0.0", Version: "14.0.0", Run: "run2"},
+		{Binary: "fzf", PreviousVersion: "0.45.0", Version: "0.46.0", Run: "run3"},
+	}}
+
+	got, record, found := PopUpgradeRecord(history, "fzf")
+	if !found {
+		t.Fatalf("PopUpgradeRecord() found = false")
+	}
+	if !reflect.DeepEqual(record, history.Upgrades[2]) {
+		t.Errorf("PopUpgradeRecord() record = %v, want %v", record, history.Upgrades[2])
+	}
+	if want := history.Upgrades[:2]; !reflect.DeepEqual(got.Upgrades, want) {
+		t.Errorf("PopUpgradeRecord() history = %v, want %v", got.Upgrades, want)
+	}
+
+	if _, _, found := PopUpgradeRecord(history, "bat"); found {
+		t.Errorf("PopUpgradeRecord() found an upgrade of a binary that was never upgraded")
+	}
+}
+
+func TestPopUpgradeAllRun(t *testing.T) {
+	history := RollbackHistory{Upgrades: []UpgradeRecord{
+		{Binary: "fzf", PreviousVersion: "0.44.0", Version: "0.45.0", Run: "run1", All: true},
+		{Binary: "rg", PreviousVersion: "13.0.0", Version: "14.0.0", Run: "run2", All: true},
+		{Binary: "bat", PreviousVersion: "v0.23.0", Version: "v0.24.0", Run: "run2", All: true},
+		{Binary: "fzf", PreviousVersion: "0.45.0", Version: "0.46.0", Run: "run3"},
+	}}
+
+	got, records, found := PopUpgradeAllRun(history)
+	if !found {
+		t.Fatalf("PopUpgradeAllRun() found = false")
+	}
+	if want := history.Upgrades[1:3]; !reflect.DeepEqual(records, want) {
+		t.Errorf("PopUpgradeAllRun() records = %v, want %v", records, want)
+	}
+	if want := []UpgradeRecord{history.Upgrades[0], history.Upgrades[3]}; !reflect.DeepEqual(got.Upgrades, want) {
+		t.Errorf("PopUpgradeAllRun() history = %v, want %v", got.Upgrades, want)
+	}
+
+	if _, _, found := PopUpgradeAllRun(RollbackHistory{Upgrades: history.Upgrades[3:]}); found {
+		t.Errorf("PopUpgradeAllRun() found a run without stew upgrade --all")
+	}
+}
+
+func TestReadRollbackHistory(t *testing.T) {
+	tempDir := t.TempDir()
+	got, err := ReadRollbackHistory(tempDir)
+	if err != nil {
+		t.Fatalf("ReadRollbackHistory() error = %v", err)
+	}
+	if len(got.Upgrades) != 0 {
+		t.Errorf("ReadRollbackHistory() = %v, want an empty history", got)
+	}
+
+	history := RollbackHistory{Upgrades: []UpgradeRecord{{Binary: "fzf", PreviousVersion: "0.44.0", Version: "0.45.0", Run: "run1", All: true}}}
+	if err := WriteRollbackHistory(tempDir, history); err != nil {
+		t.Fatalf("WriteRollbackHistory() error = %v", err)
+	}
+	got, err = ReadRollbackHistory(tempDir)
+	if err != nil {
+		t.Fatalf("ReadRollbackHistory() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, history) {
+		t.Errorf("ReadRollbackHistory() = %v, want %v", got, history)
+	}
+}
+
+func TestRetainInstalledVersion(t *testing.T) {
+	systemInfo := newTestVersionsSystemInfo(t)
+	pkg := PackageData{Source: "github", Owner: "junegunn", Repo: "fzf", Tag: "0.44.0", Asset: "fzf.tar.gz", Binary: "fzf"}
+	binaryPath := filepath.Join(systemInfo.StewBinPath, "fzf")
+	if err := os.WriteFile(binaryPath, []byte("0.44.0"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RetainInstalledVersion(systemInfo, pkg); err != nil {
+		t.Fatalf("RetainInstalledVersion() error = %v", err)
+	}
+	versionPkg, err := ReadInstalledVersion(systemInfo.StewPkgPath, "fzf", "0.44.0")
+	if err != nil {
+		t.Fatalf("ReadInstalledVersion() error = %v", err)
+	}
+	if !reflect.DeepEqual(versionPkg, pkg) {
+		t.Errorf("ReadInstalledVersion() = %v, want %v", versionPkg, pkg)
+	}
+	if got := readTestBinary(t, binaryPath); got != "0.44.0" {
+		t.Errorf("The active binary is %v, want 0.44.0", got)
+	}
+}
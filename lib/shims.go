@@ -0,0 +1,206 @@
+package stew
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// StewVersionFileName is the name of the file that pins the versions of binaries for a directory and its subdirectories
+const StewVersionFileName = ".stew-version"
+
+// ShimName returns the name of the binary that a shim was invoked as, or an empty string if stew was not invoked
+// through a shim. A shim is a link to the stew executable inside one of the stewBinPaths with a name other than
+// the name of the executable, so stew keeps working when it is renamed or installed somewhere else.
+func ShimName(invokedPath, executablePath string, stewBinPaths []string) string {
+	name := strings.TrimSuffix(filepath.Base(invokedPath), ".exe")
+	if name == strings.TrimSuffix(filepath.Base(executablePath), ".exe") {
+		return ""
+	}
+	inStewBinPath := false
+	for _, stewBinPath := range stewBinPaths {
+		if samePath(filepath.Dir(invokedPath), stewBinPath) {
+			inStewBinPath = true
+		}
+	}
+	if !inStewBinPath || !isLinkTo(invokedPath, executablePath) {
+		return ""
+	}
+	return name
+}
+
+// IsShim checks if a file in the ~/.stew/bin path is a shim for the stew executable
+func IsShim(binaryPath string) bool {
+	executablePath, err := os.Executable()
+	if err != nil {
+		return false
+	}
+	return isLinkTo(binaryPath, executablePath)
+}
+
+// isLinkTo checks if a path is a symlink or hard link to the executable
+func isLinkTo(path, executablePath string) bool {
+	pathInfo, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	executableInfo, err := os.Stat(executablePath)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(pathInfo, executableInfo)
+}
+
+// CreateShim replaces a binary in the ~/.stew/bin path with a shim that runs the version pinned for the current directory.
+// Hard links are used where symlinks can't be created.
+func CreateShim(stewBinPath, binary string) error {
+	executablePath, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	shimPath := filepath.Join(stewBinPath, binary)
+	if err := os.RemoveAll(shimPath); err != nil {
+		return err
+	}
+	if err := os.Symlink(executablePath, shimPath); err != nil {
+		return os.Link(executablePath, shimPath)
+	}
+	return nil
+}
+
+// RemoveShim links the active version of a binary back into the ~/.stew/bin path
+func RemoveShim(systemInfo SystemInfo, pkg PackageData) error {
+	versionPath := InstalledVersionPath(systemInfo.StewPkgPath, pkg.Binary, InstalledVersionName(pkg))
+	return linkFile(filepath.Join(versionPath, pkg.Binary), filepath.Join(systemInfo.StewBinPath, pkg.Binary))
+}
+
+// parseStewVersionFile parses the lines of a .stew-version file, which have the form "<binary> <version>"
+func parseStewVersionFile(path string, contents []byte) (map[string]string, error) {
+	versions := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(strings.SplitN(scanner.Text(), "#", 2)[0])
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, StewfileParseError{Path: path, Line: lineNumber, Err: errors.New("expected a binary name and a version")}
+		}
+		versions[fields[0]] = fields[1]
+	}
+	return versions, scanner.Err()
+}
+
+// ReadStewVersionFile reads the pinned versions of a .stew-version file
+func ReadStewVersionFile(path string) (map[string]string, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseStewVersionFile(path, contents)
+}
+
+// WriteStewVersionFile pins the version of a binary in the .stew-version file of a directory, keeping any other pins
+func WriteStewVersionFile(dir, binary, version string) error {
+	path := filepath.Join(dir, StewVersionFileName)
+	contents, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	lines := []string{}
+	replaced := false
+	for _, line := range strings.Split(strings.TrimRight(string(contents), "\n"), "\n") {
+		fields := strings.Fields(strings.SplitN(line, "#", 2)[0])
+		if len(fields) > 0 && fields[0] == binary {
+			line = fmt.Sprintf("%v %v", binary, version)
+			replaced = true
+		}
+		if line != "" || len(lines) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	if !replaced {
+		lines = append(lines, fmt.Sprintf("%v %v", binary, version))
+	}
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+// FindPinnedVersion looks for the version of a binary that is pinned for startDir. The nearest .stew-version file
+// or Stewfile wins, and a Stewfile only pins the binary if its own entry has a tag. It also returns the file that pinned it.
+// A .stew-version file is checked before the Stewfile in the same directory.
+func FindPinnedVersion(startDir, binary string) (string, string, bool, error) {
+	dir, err := filepath.Abs(startDir)
+	if err != nil {
+		return "", "", false, err
+	}
+	for {
+		versionFilePath := filepath.Join(dir, StewVersionFileName)
+		if versionFileExists, _ := PathExists(versionFilePath); versionFileExists {
+			versions, err := ReadStewVersionFile(versionFilePath)
+			if err != nil {
+				return "", "", false, err
+			}
+			if version, found := versions[binary]; found {
+				return version, versionFilePath, true, nil
+			}
+		}
+
+		for _, stewfileName := range []string{"Stewfile", "Stewfile.toml"} {
+			stewfilePath := filepath.Join(dir, stewfileName)
+			if stewfileExists, _ := PathExists(stewfilePath); !stewfileExists {
+				continue
+			}
+			// A Stewfile that can't be read doesn't pin anything, so that it can't break the binaries of unrelated projects.
+			// Its includes aren't followed, since a shim must not read remote Stewfiles every time it runs.
+			packages, err := ReadLocalStewfileContents(stewfilePath)
+			if err != nil {
+				continue
+			}
+			for _, pkg := range packages {
+				if (pkg.Binary == binary || pkg.Binary == "" && pkg.Repo == binary) && pkg.Tag != "" {
+					return pkg.Tag, stewfilePath, true, nil
+				}
+			}
+		}
+
+		parentDir := filepath.Dir(dir)
+		if parentDir == dir {
+			return "", "", false, nil
+		}
+		dir = parentDir
+	}
+}
+
+// ResolveShim finds the installed binary that a shim should run in workingDir. The version pinned for the directory is
+// used if there is one, and the active version otherwise. The project environment is searched before the global one.
+func ResolveShim(systemInfos []SystemInfo, lockFiles []LockFile, workingDir, binary string) (string, error) {
+	version, pinnedBy, pinned, err := FindPinnedVersion(workingDir, binary)
+	if err != nil {
+		return "", err
+	}
+	for i, systemInfo := range systemInfos {
+		if !pinned {
+			indexInLockFile, found := FindBinaryInLockFile(lockFiles[i], binary)
+			if !found {
+				continue
+			}
+			version = InstalledVersionName(lockFiles[i].Packages[indexInLockFile])
+		}
+		binaryPath := filepath.Join(InstalledVersionPath(systemInfo.StewPkgPath, binary, version), binary)
+		if binaryExists, _ := PathExists(binaryPath); binaryExists {
+			return binaryPath, nil
+		}
+	}
+	if pinned {
+		return "", PinnedVersionNotInstalledError{Binary: binary, Version: version, PinnedBy: pinnedBy}
+	}
+	return "", BinaryNotInstalledError{Binary: binary}
+}
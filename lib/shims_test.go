@@ -0,0 +1,257 @@
+package stew
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestShimName(t *testing.T) {
+	tempDir := t.TempDir()
+	executablePath := filepath.Join(tempDir, "opt", "stew")
+	stewBinPath := filepath.Join(tempDir, "bin")
+	otherBinPath := filepath.Join(tempDir, "usr", "bin")
+	for _, path := range []string{filepath.Dir(executablePath), stewBinPath, otherBinPath} {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(executablePath, []byte("stew"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, link := range []string{filepath.Join(stewBinPath, "kubectl"), filepath.Join(stewBinPath, "stew"), filepath.Join(otherBinPath, "st")} {
+		if err := os.Symlink(executablePath, link); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(stewBinPath, "fzf"), []byte("fzf"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		invokedPath string
+		want        string
+	}{
+		{
+			name:        "test1",
+			invokedPath: filepath.Join(stewBinPath, "kubectl"),
+			want:        "kubectl",
+		},
+		{
+			name:        "test2",
+			invokedPath: executablePath,
+			want:        "",
+		},
+		{
+			name:        "test3",
+			invokedPath: filepath.Join(stewBinPath, "stew"),
+			want:        "",
+		},
+		{
+			name:        "test4",
+			invokedPath: filepath.Join(otherBinPath, "st"),
+			want:        "",
+		},
+		{
+			name:        "test5",
+			invokedPath: filepath.Join(stewBinPath, "fzf"),
+			want:        "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ShimName(tt.invokedPath, executablePath, []string{stewBinPath}); got != tt.want {
+				t.Errorf("ShimName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseStewVersionFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     map[string]string
+		wantErr  bool
+	}{
+		{
+			name:     "test1",
+			contents: "# Pinned tools\nkubectl v1.27.3\n\nterraform   v1.5.7 # for the old modules\n",
+			want:     map[string]string{"kubectl": "v1.27.3", "terraform": "v1.5.7"},
+		},
+		{
+			name:     "test2",
+			contents: "kubectl\n",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStewVersionFile(".stew-version", []byte(tt.contents))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseStewVersionFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseStewVersionFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteStewVersionFile(t *testing.T) {
+	tempDir := t.TempDir()
+	versionFilePath := filepath.Join(tempDir, StewVersionFileName)
+	if err := os.WriteFile(versionFilePath, []byte("# Pinned tools\nkubectl v1.27.3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteStewVersionFile(tempDir, "terraform", "v1.5.7"); err != nil {
+		t.Fatalf("WriteStewVersionFile() error = %v", err)
+	}
+	if err := WriteStewVersionFile(tempDir, "kubectl", "v1.30.0"); err != nil {
+		t.Fatalf("WriteStewVersionFile() error = %v", err)
+	}
+
+	got, err := os.ReadFile(versionFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Pinned tools\nkubectl v1.30.0\nterraform v1.5.7\n"
+	if string(got) != want {
+		t.Errorf("WriteStewVersionFile() wrote %q, want %q", got, want)
+	}
+}
+
+func TestFindPinnedVersion(t *testing.T) {
+	tempDir := t.TempDir()
+	projectPath := filepath.Join(tempDir, "project")
+	nestedPath := filepath.Join(projectPath, "deploy")
+	if err := os.MkdirAll(nestedPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// The remote include of the Stewfile must not be fetched when a shim looks for a pinned version
+	remoteRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remoteRequests++
+		w.Write([]byte("junegunn/fzf@0.44.0\n"))
+	}))
+	defer server.Close()
+	stewfileContents := "include " + server.URL + "/Stewfile\njunegunn/fzf@0.45.0\nkubernetes/kubectl@v1.30.0\n"
+	if err := os.WriteFile(filepath.Join(projectPath, "Stewfile"), []byte(stewfileContents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(nestedPath, StewVersionFileName), []byte("kubectl v1.27.3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		startDir     string
+		binary       string
+		want         string
+		wantPinnedBy string
+		wantPinned   bool
+	}{
+		{
+			name:         "test1",
+			startDir:     nestedPath,
+			binary:       "kubectl",
+			want:         "v1.27.3",
+			wantPinnedBy: filepath.Join(nestedPath, StewVersionFileName),
+			wantPinned:   true,
+		},
+		{
+			name:         "test2",
+			startDir:     nestedPath,
+			binary:       "fzf",
+			want:         "0.45.0",
+			wantPinnedBy: filepath.Join(projectPath, "Stewfile"),
+			wantPinned:   true,
+		},
+		{
+			name:         "test3",
+			startDir:     projectPath,
+			binary:       "kubectl",
+			want:         "v1.30.0",
+			wantPinnedBy: filepath.Join(projectPath, "Stewfile"),
+			wantPinned:   true,
+		},
+		{
+			name:       "test4",
+			startDir:   projectPath,
+			binary:     "terraform",
+			wantPinned: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, pinnedBy, pinned, err := FindPinnedVersion(tt.startDir, tt.binary)
+			if err != nil {
+				t.Fatalf("FindPinnedVersion() error = %v", err)
+			}
+			if got != tt.want || pinnedBy != tt.wantPinnedBy || pinned != tt.wantPinned {
+				t.Errorf("FindPinnedVersion() = %v, %v, %v, want %v, %v, %v", got, pinnedBy, pinned, tt.want, tt.wantPinnedBy, tt.wantPinned)
+			}
+		})
+	}
+	if remoteRequests != 0 {
+		t.Errorf("FindPinnedVersion() fetched the remote include %v times", remoteRequests)
+	}
+}
+
+func TestResolveShim(t *testing.T) {
+	systemInfo := newTestVersionsSystemInfo(t)
+	oldPkg := PackageData{Source: "github", Owner: "kubernetes", Repo: "kubectl", Tag: "v1.27.3", Asset: "kubectl-1.27.3", Binary: "kubectl"}
+	newPkg := PackageData{Source: "github", Owner: "kubernetes", Repo: "kubectl", Tag: "v1.30.0", Asset: "kubectl-1.30.0", Binary: "kubectl"}
+	installTestVersion(t, systemInfo, oldPkg)
+	installTestVersion(t, systemInfo, newPkg)
+	lockFile := LockFile{Packages: []PackageData{newPkg}}
+
+	projectPath := t.TempDir()
+	otherPath := t.TempDir()
+	if err := WriteStewVersionFile(projectPath, "kubectl", "v1.27.3"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		workingDir string
+		binary     string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "test1",
+			workingDir: projectPath,
+			binary:     "kubectl",
+			want:       filepath.Join(systemInfo.StewPkgPath, "kubectl", "v1.27.3", "kubectl"),
+		},
+		{
+			name:       "test2",
+			workingDir: otherPath,
+			binary:     "kubectl",
+			want:       filepath.Join(systemInfo.StewPkgPath, "kubectl", "v1.30.0", "kubectl"),
+		},
+		{
+			name:       "test3",
+			workingDir: otherPath,
+			binary:     "terraform",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveShim([]SystemInfo{systemInfo}, []LockFile{lockFile}, tt.workingDir, tt.binary)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveShim() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveShim() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,11 +2,15 @@ package stew
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/marwanhawari/stew/constants"
@@ -14,9 +18,12 @@ import (
 
 // LockFile contains all the data for the lockfile
 type LockFile struct {
-	Os       string        `json:"os"`
-	Arch     string        `json:"arch"`
-	Packages []PackageData `json:"packages"`
+	SchemaVersion int           `json:"schemaVersion"`
+	Os            string        `json:"os"`
+	Arch          string        `json:"arch"`
+	Packages      []PackageData `json:"packages"`
+	// SelectedGroups are the Stewfile groups that were installed, where no groups means all of them
+	SelectedGroups []string `json:"selectedGroups,omitempty"`
 }
 
 // PackageData contains the information for an installed binary
@@ -30,6 +37,66 @@ type PackageData struct {
 	URL    string   `json:"url"`
 	Groups []string `json:"groups"`
 	Host   string   `json:"host"`
+
+	// BinarySHA256 is the SHA256 digest of the installed binary, which stew verify checks it against
+	BinarySHA256 string `json:"binarySha256,omitempty"`
+	// ExtraFilesSHA256 maps the installed extra files of the binary to their SHA256 digests
+	ExtraFilesSHA256 map[string]string `json:"extraFilesSha256,omitempty"`
+
+	Platforms map[string]PlatformData `json:"platforms,omitempty"`
+
+	// The remaining fields can only be set from a structured Stewfile
+	AssetPattern        string                      `json:"assetPattern,omitempty"`
+	Constraint          string                      `json:"constraint,omitempty"`
+	Channel             string                      `json:"channel,omitempty"`
+	ExtraFiles          []string                    `json:"extraFiles,omitempty"`
+	InstalledExtraFiles []string                    `json:"installedExtraFiles,omitempty"`
+	Overrides           map[string]PlatformOverride `json:"overrides,omitempty"`
+	StewfileGroups      []string                    `json:"stewfileGroups,omitempty"`
+	OnlyOS              []string                    `json:"onlyOS,omitempty"`
+	OnlyArch            []string                    `json:"onlyArch,omitempty"`
+}
+
+// PlatformOverride replaces the asset selection of a package for a specific OS/arch
+type PlatformOverride struct {
+	Asset        string `json:"asset,omitempty"`
+	AssetPattern string `json:"assetPattern,omitempty"`
+}
+
+// PlatformData contains the release asset of a package for a specific OS/arch
+type PlatformData struct {
+	Asset  string `json:"asset"`
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+}
+
+// PlatformKey returns the key used for an OS/arch pair in PackageData.Platforms [Ex: linux/amd64]
+func PlatformKey(userOS, userArch string) string {
+	return userOS + "/" + userArch
+}
+
+// ParsePlatform splits a platform key into its OS and arch and makes sure they are supported
+func ParsePlatform(platform string) (string, string, error) {
+	platformOS, platformArch, found := strings.Cut(strings.TrimSpace(platform), "/")
+	if !found {
+		return "", "", UnsupportedPlatformError{OS: platform}
+	}
+	if err := ValidatePlatform(platformOS, platformArch); err != nil {
+		return "", "", err
+	}
+	return platformOS, platformArch, nil
+}
+
+// FindPlatformData returns the locked asset of a package for an OS/arch. Packages without a platforms entry
+// fall back to their top level asset if the lockfile was written for the same OS/arch.
+func FindPlatformData(lockFile LockFile, pkg PackageData, userOS, userArch string) (PlatformData, bool) {
+	if platformData, found := pkg.Platforms[PlatformKey(userOS, userArch)]; found {
+		return platformData, true
+	}
+	if lockFile.Os == userOS && lockFile.Arch == userArch {
+		return PlatformData{Asset: pkg.Asset, URL: pkg.URL}, true
+	}
+	return PlatformData{}, false
 }
 
 func readLockFileJSON(lockFilePath string) (LockFile, error) {
@@ -38,23 +105,77 @@ func readLockFileJSON(lockFilePath string) (LockFile, error) {
 		return LockFile{}, err
 	}
 
+	// Check the schema first so that fields of newer schemas aren't silently dropped
+	var schema struct {
+		SchemaVersion int `json:"schemaVersion"`
+	}
+	err = json.Unmarshal(lockFileBytes, &schema)
+	if err != nil {
+		return LockFile{}, err
+	}
+	if schema.SchemaVersion > LockFileSchemaVersion {
+		return LockFile{}, UnsupportedLockFileSchemaError{
+			Path:             lockFilePath,
+			SchemaVersion:    schema.SchemaVersion,
+			SupportedVersion: LockFileSchemaVersion,
+		}
+	}
+
 	var lockFile LockFile
 	err = json.Unmarshal(lockFileBytes, &lockFile)
 	if err != nil {
 		return LockFile{}, err
 	}
 
-	return lockFile, nil
+	return MigrateLockFile(lockFile), nil
+}
+
+// LockFileSchemaVersion is the version of the lockfile format that is written by this version of stew
+const LockFileSchemaVersion = 2
+
+// MigrateLockFile upgrades a lockfile that was written with an older schema to the current schema.
+// Lockfiles without a schema version were written before the version was recorded.
+func MigrateLockFile(lockFile LockFile) LockFile {
+	if lockFile.SchemaVersion < 2 {
+		// Version 2 always records the source, and the groups of GitLab packages separately from the owner
+		for index, pkg := range lockFile.Packages {
+			if pkg.Source == "" {
+				lockFile.Packages[index].Source = "github"
+			}
+			if pkg.Source == "gitlab" && len(pkg.Groups) == 0 && pkg.Owner != "" {
+				lockFile.Packages[index].Groups = strings.Split(pkg.Owner, "/")
+			}
+		}
+	}
+	lockFile.SchemaVersion = LockFileSchemaVersion
+	return lockFile
+}
+
+// SortLockFilePackages sorts lockfile packages by their binary name, and then by their Stewfile entry for
+// packages that aren't installed. A stable order keeps the lockfile diffs of separate changes from conflicting.
+func SortLockFilePackages(packages []PackageData) []PackageData {
+	sortedPackages := make([]PackageData, len(packages))
+	copy(sortedPackages, packages)
+	sort.SliceStable(sortedPackages, func(i, j int) bool {
+		if sortedPackages[i].Binary != sortedPackages[j].Binary {
+			return sortedPackages[i].Binary < sortedPackages[j].Binary
+		}
+		return FormatStewfileLine(sortedPackages[i], false, false) < FormatStewfileLine(sortedPackages[j], false, false)
+	})
+	return sortedPackages
 }
 
 // WriteLockFileJSON will write the lockfile JSON file
 func WriteLockFileJSON(lockFileJSON LockFile, outputPath string) error {
+	lockFileJSON.SchemaVersion = LockFileSchemaVersion
+	lockFileJSON.Packages = SortLockFilePackages(lockFileJSON.Packages)
 	lockFileBytes, err := json.MarshalIndent(lockFileJSON, "", "\t")
 	if err != nil {
 		return err
 	}
+	lockFileBytes = append(lockFileBytes, '\n')
 
-	err = os.WriteFile(outputPath, lockFileBytes, 0644)
+	err = writeFileAtomic(outputPath, lockFileBytes, 0644)
 	if err != nil {
 		return err
 	}
@@ -79,71 +200,499 @@ func RemovePackage(pkgs []PackageData, index int) ([]PackageData, error) {
 
 // ReadStewfileContents will read the contents of the Stewfile
 func ReadStewfileContents(stewfilePath string) ([]PackageData, error) {
-	file, err := os.Open(stewfilePath)
+	packages, errs, err := parseStewfile(stewfilePath)
 	if err != nil {
 		return []PackageData{}, err
 	}
-	defer file.Close()
+	if len(errs) > 0 {
+		return []PackageData{}, errs[0]
+	}
+	return packages, nil
+}
 
-	scanner := bufio.NewScanner(file)
+// ReadLocalStewfileContents is like ReadStewfileContents, but it skips the include directives of the Stewfile, so
+// that it never reads other files or makes network requests
+func ReadLocalStewfileContents(stewfilePath string) ([]PackageData, error) {
+	packages, errs, err := parseStewfileLocation(stewfilePath, "", []string{}, false)
+	if err != nil {
+		return []PackageData{}, err
+	}
+	if len(errs) > 0 {
+		return []PackageData{}, errs[0]
+	}
+	return packages, nil
+}
+
+// ValidateStewfile will parse the Stewfile and return every problem that was found in it
+func ValidateStewfile(stewfilePath string) ([]PackageData, []error, error) {
+	return parseStewfile(stewfilePath)
+}
+
+var stewfileSources = []string{"github", "gitlab", "gitea"}
+
+var stewfileOptions = []string{"source", "host", "os", "arch", "asset.<os>/<arch>"}
+
+// parseStewfile parses every line of a Stewfile and the Stewfiles it includes. Problems with individual lines are
+// collected as StewfileParseErrors, while the returned error is only set if the file itself could not be read.
+func parseStewfile(stewfilePath string) ([]PackageData, []error, error) {
+	return parseStewfileLocation(stewfilePath, "", []string{}, true)
+}
 
-	var packages []PackageData
+// parseStewfileLocation parses the Stewfile at a path or URL, checking its SHA256 digest if one is expected.
+// The include stack holds the Stewfiles that led to this one and is used to detect include cycles. Include
+// directives are skipped unless resolveIncludes is set.
+func parseStewfileLocation(location, expectedSHA256 string, includeStack []string, resolveIncludes bool) ([]PackageData, []error, error) {
+	contents, err := readStewfileLocation(location)
+	if err != nil {
+		return []PackageData{}, []error{}, err
+	}
+	if expectedSHA256 != "" {
+		if actualSHA256 := sha256Hex(contents); !strings.EqualFold(actualSHA256, expectedSHA256) {
+			return []PackageData{}, []error{}, ChecksumMismatchError{Asset: location, Expected: expectedSHA256, Actual: actualSHA256}
+		}
+	}
+	includeStack = append(includeStack, location)
+
+	if IsStructuredStewfile(location) {
+		return parseStructuredStewfile(location, contents, includeStack, resolveIncludes)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+
+	merged := newStewfileMerge()
+	errs := []error{}
+	lineNumbers := map[string]int{}
+	group := ""
+	lineNumber := 0
 	for scanner.Scan() {
-		line := scanner.Text()
-		packageAndOptions := strings.SplitN(line, "?", 2)
-		packageString := packageAndOptions[0]
-		options := make(map[string]string, 0)
-		if len(packageAndOptions) == 2 {
-			optionsSplit := strings.Split(packageAndOptions[1], "&")
-			for _, option := range optionsSplit {
-				optionkv := strings.Split(option, "=")
-				options[optionkv[0]] = optionkv[1]
-			}
-		}
-		if strings.HasPrefix(packageString, "https") || strings.HasPrefix(packageString, "http") {
-			p := PackageData{
-				URL:    packageString,
-				Source: "other",
-			}
-			packages = append(packages, p)
+		lineNumber++
+		line := stripStewfileComment(scanner.Text())
+		if line == "" {
 			continue
 		}
-		splitInput := strings.Split(packageString, "@")
-		groups := []string{}
-		owner := ""
-		repo := ""
-		if len(strings.Split(splitInput[0], "/")) > 2 {
-			groups = strings.Split(path.Dir(splitInput[0]), "/")
-			repo = path.Base(splitInput[0])
-		} else {
-			owner = strings.Split(splitInput[0], "/")[0]
-			repo = strings.Split(splitInput[0], "/")[1]
-		}
-		p := PackageData{
-			Repo:   repo,
-			Owner:  owner,
-			Groups: groups,
+		if strings.HasPrefix(line, "[") {
+			group, err = parseStewfileGroupHeader(line)
+			if err != nil {
+				errs = append(errs, StewfileParseError{Path: location, Line: lineNumber, Err: err})
+			}
+			continue
 		}
-		if len(splitInput) == 2 {
-			tagAndAsset := strings.Split(splitInput[1], "#")
-			if len(tagAndAsset) == 2 {
-				p.Asset = tagAndAsset[1]
+		if include, isInclude := strings.CutPrefix(line, "include "); isInclude {
+			includePath, includeSHA256, err := parseStewfileInclude(include)
+			if err != nil {
+				errs = append(errs, StewfileParseError{Path: location, Line: lineNumber, Err: err})
+				continue
+			}
+			if !resolveIncludes {
+				continue
+			}
+			includedPackages, includeErrs, err := includeStewfile(location, includePath, includeSHA256, includeStack)
+			if err != nil {
+				errs = append(errs, StewfileParseError{Path: location, Line: lineNumber, Err: err})
+				continue
 			}
-			p.Tag = tagAndAsset[0]
+			errs = append(errs, includeErrs...)
+			for _, p := range includedPackages {
+				merged.add(p)
+			}
+			continue
+		}
+		p, err := parseStewfileLine(line)
+		if err != nil {
+			errs = append(errs, StewfileParseError{Path: location, Line: lineNumber, Err: err})
+			continue
+		}
+		if group != "" {
+			p.StewfileGroups = []string{group}
 		}
-		p.Host = options["host"]
-		p.Source = "github"
-		if options["source"] != "" {
-			p.Source = options["source"]
+		key := FormatStewfileLine(p, false, false)
+		if previousLineNumber, found := lineNumbers[key]; found {
+			// The same entry can be listed in several groups
+			previous := &merged.packages[merged.indexes[key]]
+			_, inGroup := Contains(previous.StewfileGroups, group)
+			if group != "" && !inGroup && len(previous.StewfileGroups) > 0 &&
+				FormatStewfileLine(*previous, true, true) == FormatStewfileLine(p, true, true) {
+				previous.StewfileGroups = append(previous.StewfileGroups, group)
+				continue
+			}
+			errs = append(errs, StewfileParseError{
+				Path: location,
+				Line: lineNumber,
+				Err:  fmt.Errorf("%v is already listed on line %v", key, previousLineNumber),
+			})
+			continue
 		}
-		packages = append(packages, p)
+		lineNumbers[key] = lineNumber
+		merged.add(p)
 	}
 
 	if err := scanner.Err(); err != nil {
-		return []PackageData{}, err
+		return []PackageData{}, []error{}, err
 	}
 
-	return packages, nil
+	return merged.packages, errs, nil
+}
+
+var stewfileGroupRegex = regexp.MustCompile(`^[A-Za-z0-9_\-]+$`)
+
+// parseStewfileGroupHeader parses a [group.<name>] line, which puts all of the entries below it into that group
+func parseStewfileGroupHeader(line string) (string, error) {
+	header, isGroup := strings.CutPrefix(line, "[group.")
+	if !isGroup || !strings.HasSuffix(header, "]") {
+		return "", fmt.Errorf("%v is not a valid group header, expected [group.<name>]", line)
+	}
+	group := strings.TrimSuffix(header, "]")
+	if !stewfileGroupRegex.MatchString(group) {
+		return "", fmt.Errorf("%v is not a valid group name", group)
+	}
+	return group, nil
+}
+
+// StewfileGroupNames returns the names of all of the groups used in a Stewfile in the order they first appear
+func StewfileGroupNames(packages []PackageData) []string {
+	groups := []string{}
+	for _, pkg := range packages {
+		for _, group := range pkg.StewfileGroups {
+			if _, found := Contains(groups, group); !found {
+				groups = append(groups, group)
+			}
+		}
+	}
+	return groups
+}
+
+// InSelectedGroups checks if a package is part of a group selection.
+// Packages without a group and selections without any groups always match.
+func InSelectedGroups(pkg PackageData, selectedGroups []string) bool {
+	if len(pkg.StewfileGroups) == 0 || len(selectedGroups) == 0 {
+		return true
+	}
+	for _, group := range pkg.StewfileGroups {
+		if _, found := Contains(selectedGroups, group); found {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterStewfileGroups returns the Stewfile packages that are part of a group selection.
+// Selecting a group that doesn't exist in the Stewfile is an error.
+func FilterStewfileGroups(packages []PackageData, selectedGroups []string) ([]PackageData, error) {
+	groups := StewfileGroupNames(packages)
+	for _, group := range selectedGroups {
+		if _, found := Contains(groups, group); !found {
+			return []PackageData{}, StewfileGroupNotFoundError{Group: group, Groups: groups}
+		}
+	}
+
+	selectedPackages := []PackageData{}
+	for _, pkg := range packages {
+		if InSelectedGroups(pkg, selectedGroups) {
+			selectedPackages = append(selectedPackages, pkg)
+		}
+	}
+	return selectedPackages, nil
+}
+
+// stripStewfileComment removes a trailing comment and surrounding whitespace from a Stewfile line.
+// A # only starts a comment at the beginning of a line or after whitespace, because it also separates tags from assets.
+func stripStewfileComment(line string) string {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "#") {
+		return ""
+	}
+	for _, separator := range []string{" #", "\t#"} {
+		if index := strings.Index(line, separator); index != -1 {
+			line = line[:index]
+		}
+	}
+	return strings.TrimSpace(line)
+}
+
+// parseStewfileLine parses a single owner/repo@tag#asset?source=x&host=y or URL entry of a Stewfile
+func parseStewfileLine(line string) (PackageData, error) {
+	packageAndOptions := strings.SplitN(line, "?", 2)
+	packageString := packageAndOptions[0]
+
+	if strings.HasPrefix(packageString, "https://") || strings.HasPrefix(packageString, "http://") {
+		return parseStewfileURLLine(line)
+	}
+
+	options := make(map[string]string, 0)
+	var overrides map[string]PlatformOverride
+	if len(packageAndOptions) == 2 {
+		for _, option := range strings.Split(packageAndOptions[1], "&") {
+			key, value, found := strings.Cut(option, "=")
+			if !found || value == "" {
+				return PackageData{}, fmt.Errorf("the option %v must be written as key=value", option)
+			}
+			if platform, isOverride := strings.CutPrefix(key, "asset."); isOverride {
+				if _, _, err := ParsePlatform(platform); err != nil {
+					return PackageData{}, fmt.Errorf("unknown platform %v in the option %v", platform, key)
+				}
+				if overrides == nil {
+					overrides = map[string]PlatformOverride{}
+				}
+				overrides[platform] = PlatformOverride{Asset: value}
+				continue
+			}
+			if _, known := Contains(stewfileOptions, key); !known {
+				return PackageData{}, fmt.Errorf("unknown option %v, expected one of %v", key, strings.Join(stewfileOptions, ", "))
+			}
+			options[key] = value
+		}
+	}
+
+	p := PackageData{Source: "github", Host: options["host"], Overrides: overrides}
+	if options["os"] != "" {
+		p.OnlyOS = strings.Split(options["os"], ",")
+	}
+	if options["arch"] != "" {
+		p.OnlyArch = strings.Split(options["arch"], ",")
+	}
+	if err := validatePlatformRestriction(p.OnlyOS, p.OnlyArch); err != nil {
+		return PackageData{}, err
+	}
+	if options["source"] != "" {
+		p.Source = options["source"]
+	}
+	if _, known := Contains(stewfileSources, p.Source); !known {
+		return PackageData{}, fmt.Errorf("unknown source %v, expected one of %v", p.Source, strings.Join(stewfileSources, ", "))
+	}
+	if p.Host != "" && !stewfileHostRegex.MatchString(p.Host) {
+		return PackageData{}, fmt.Errorf("%v is not a valid host", p.Host)
+	}
+	if p.Host == "" && p.Source == "gitea" {
+		return PackageData{}, fmt.Errorf("the %v source requires a host option", p.Source)
+	}
+
+	repoPath, tagAndAsset, hasTag := strings.Cut(packageString, "@")
+	segments := strings.Split(repoPath, "/")
+	if len(segments) < 2 {
+		return PackageData{}, fmt.Errorf("%v must be written as owner/repo", repoPath)
+	}
+	for _, segment := range segments {
+		if segment == "" {
+			return PackageData{}, fmt.Errorf("%v must be written as owner/repo", repoPath)
+		}
+	}
+	if len(segments) > 2 {
+		p.Groups = strings.Split(path.Dir(repoPath), "/")
+	} else {
+		p.Owner = segments[0]
+	}
+	p.Repo = path.Base(repoPath)
+
+	if hasTag {
+		tag, asset, _ := strings.Cut(tagAndAsset, "#")
+		if tag == "" {
+			return PackageData{}, fmt.Errorf("missing tag after @ in %v", packageString)
+		}
+		p.Tag = tag
+		p.Asset = asset
+	}
+
+	return p, nil
+}
+
+var stewfileURLOptions = []string{"os", "arch"}
+
+// parseStewfileURLLine parses a URL entry of a Stewfile. The query of the URL is only read as the os and arch options
+// if it has nothing else, so that download URLs with their own query parameters keep working.
+func parseStewfileURLLine(line string) (PackageData, error) {
+	p := PackageData{URL: line, Source: "other"}
+	if downloadURL, query, hasQuery := strings.Cut(line, "?"); hasQuery {
+		options := map[string]string{}
+		for _, option := range strings.Split(query, "&") {
+			key, value, found := strings.Cut(option, "=")
+			if _, known := Contains(stewfileURLOptions, key); !found || value == "" || !known {
+				options = nil
+				break
+			}
+			options[key] = value
+		}
+		if options != nil {
+			p.URL = downloadURL
+			if options["os"] != "" {
+				p.OnlyOS = strings.Split(options["os"], ",")
+			}
+			if options["arch"] != "" {
+				p.OnlyArch = strings.Split(options["arch"], ",")
+			}
+			if err := validatePlatformRestriction(p.OnlyOS, p.OnlyArch); err != nil {
+				return PackageData{}, err
+			}
+		}
+	}
+
+	parsedURL, err := url.Parse(p.URL)
+	if err != nil || parsedURL.Host == "" {
+		return PackageData{}, fmt.Errorf("%v is not a valid URL", p.URL)
+	}
+	return p, nil
+}
+
+var stewfileHostRegex = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9\-\.]*[A-Za-z0-9])?(:[0-9]+)?$`)
+
+// ValidateFrozenLockFile makes sure a lockfile contains exactly the packages of a Stewfile, with the same pinned tags and assets.
+// Only the entries that are installed on the target platform and in the selected groups are compared, since stew lock
+// leaves out the entries that aren't used on any of the locked platforms.
+func ValidateFrozenLockFile(stewfilePackages []PackageData, lockFile LockFile, targetOS, targetArch string, selectedGroups []string) error {
+	stewfilePackages = filterFrozenPackages(stewfilePackages, targetOS, targetArch, selectedGroups)
+	lockedPackages := filterFrozenPackages(lockFile.Packages, targetOS, targetArch, selectedGroups)
+	if len(stewfilePackages) != len(lockedPackages) {
+		return FrozenLockFileError{
+			Package: "Stewfile.lock.json",
+			Reason:  fmt.Sprintf("the Stewfile has %v entries for %v but the lockfile has %v", len(stewfilePackages), PlatformKey(targetOS, targetArch), len(lockedPackages)),
+		}
+	}
+	lockFile.Packages = lockedPackages
+	for _, pkg := range stewfilePackages {
+		name := pkg.Owner + "/" + pkg.Repo
+		if pkg.Source == "other" {
+			name = pkg.URL
+		}
+		index, found := FindPackageInLockFile(lockFile, pkg)
+		if !found {
+			return FrozenLockFileError{Package: name, Reason: "it is missing from the lockfile"}
+		}
+		lockedPkg := lockFile.Packages[index]
+		if pkg.Tag != "" && pkg.Tag != "latest" && pkg.Tag != lockedPkg.Tag {
+			return FrozenLockFileError{Package: name, Reason: fmt.Sprintf("the lockfile has tag %v", lockedPkg.Tag)}
+		}
+		if pkg.Asset != "" && pkg.Asset != lockedPkg.Asset {
+			return FrozenLockFileError{Package: name, Reason: fmt.Sprintf("the lockfile has asset %v", lockedPkg.Asset)}
+		}
+	}
+	return nil
+}
+
+// FindFrozenPlatformData returns the locked asset of a package for an OS/arch for a frozen install. The asset must have
+// a SHA256 digest in the lockfile, since a frozen install can't make sure that it gets the locked asset otherwise.
+func FindFrozenPlatformData(lockFile LockFile, pkg PackageData, targetOS, targetArch string) (PlatformData, error) {
+	platformData, found := FindPlatformData(lockFile, pkg, targetOS, targetArch)
+	if !found || platformData.URL == "" {
+		return PlatformData{}, FrozenLockFileError{Package: pkg.Binary, Reason: "no asset is locked for " + PlatformKey(targetOS, targetArch)}
+	}
+	if platformData.SHA256 == "" {
+		return PlatformData{}, FrozenLockFileError{
+			Package: pkg.Binary,
+			Reason:  fmt.Sprintf("no SHA256 digest is locked for %v. Run stew lock to record one.", PlatformKey(targetOS, targetArch)),
+		}
+	}
+	return platformData, nil
+}
+
+// filterFrozenPackages returns the packages that are used on a platform and are part of a group selection
+func filterFrozenPackages(packages []PackageData, targetOS, targetArch string, selectedGroups []string) []PackageData {
+	filteredPackages := []PackageData{}
+	for _, pkg := range packages {
+		if MatchesPlatform(pkg, targetOS, targetArch) && InSelectedGroups(pkg, selectedGroups) {
+			filteredPackages = append(filteredPackages, pkg)
+		}
+	}
+	return filteredPackages
+}
+
+// ReadStewLockFile will read a lockfile that is expected to exist, such as one passed on the command line
+func ReadStewLockFile(lockFilePath string) (LockFile, error) {
+	return readLockFileJSON(lockFilePath)
+}
+
+// FormatStewfileLine formats a lockfile entry as a Stewfile line that ReadStewfileContents understands.
+// The asset is only included along with the tag because asset names usually contain the version.
+func FormatStewfileLine(pkg PackageData, includeTag, includeAsset bool) string {
+	if pkg.Source == "other" {
+		if options := platformRestrictionOptions(pkg); len(options) > 0 {
+			return pkg.URL + "?" + strings.Join(options, "&")
+		}
+		return pkg.URL
+	}
+
+	line := GetPackageOwner(pkg) + "/" + pkg.Repo
+	if includeTag && pkg.Tag != "" {
+		line += "@" + pkg.Tag
+		if includeAsset && pkg.Asset != "" {
+			line += "#" + pkg.Asset
+		}
+	}
+
+	options := []string{}
+	if pkg.Source != "" && pkg.Source != "github" {
+		options = append(options, "source="+pkg.Source)
+		if pkg.Host != "" {
+			options = append(options, "host="+pkg.Host)
+		}
+	}
+	options = append(options, platformRestrictionOptions(pkg)...)
+	if includeAsset {
+		platforms := []string{}
+		for platform, override := range pkg.Overrides {
+			if override.Asset != "" {
+				platforms = append(platforms, platform)
+			}
+		}
+		sort.Strings(platforms)
+		for _, platform := range platforms {
+			options = append(options, "asset."+platform+"="+pkg.Overrides[platform].Asset)
+		}
+	}
+	if len(options) > 0 {
+		line += "?" + strings.Join(options, "&")
+	}
+
+	return line
+}
+
+// platformRestrictionOptions formats the OS and arch restrictions of a package as Stewfile options
+func platformRestrictionOptions(pkg PackageData) []string {
+	options := []string{}
+	if len(pkg.OnlyOS) > 0 {
+		options = append(options, "os="+strings.Join(pkg.OnlyOS, ","))
+	}
+	if len(pkg.OnlyArch) > 0 {
+		options = append(options, "arch="+strings.Join(pkg.OnlyArch, ","))
+	}
+	return options
+}
+
+// validatePlatformRestriction makes sure that the OS and arch restrictions of a Stewfile entry are supported platforms
+func validatePlatformRestriction(onlyOS, onlyArch []string) error {
+	for _, restrictedOS := range onlyOS {
+		if _, found := Contains(supportedOperatingSystems, restrictedOS); !found {
+			return fmt.Errorf("unknown os %v, expected one of %v", restrictedOS, strings.Join(supportedOperatingSystems, ", "))
+		}
+	}
+	for _, restrictedArch := range onlyArch {
+		if _, found := Contains(supportedArchitectures, restrictedArch); !found {
+			return fmt.Errorf("unknown arch %v, expected one of %v", restrictedArch, strings.Join(supportedArchitectures, ", "))
+		}
+	}
+	return nil
+}
+
+// MatchesPlatform checks if a package should be installed on an OS/arch.
+// Packages without any OS or arch restrictions match every platform.
+func MatchesPlatform(pkg PackageData, targetOS, targetArch string) bool {
+	if _, found := Contains(pkg.OnlyOS, targetOS); len(pkg.OnlyOS) > 0 && !found {
+		return false
+	}
+	if _, found := Contains(pkg.OnlyArch, targetArch); len(pkg.OnlyArch) > 0 && !found {
+		return false
+	}
+	return true
+}
+
+// FilterPlatforms returns the OS/arch platforms that a package should be installed on
+func FilterPlatforms(pkg PackageData, platforms []string) []string {
+	matchingPlatforms := []string{}
+	for _, platform := range platforms {
+		platformOS, platformArch, _ := strings.Cut(platform, "/")
+		if MatchesPlatform(pkg, platformOS, platformArch) {
+			matchingPlatforms = append(matchingPlatforms, platform)
+		}
+	}
+	return matchingPlatforms
 }
 
 func ReadStewLockFileContents(lockFilePath string) ([]PackageData, error) {
@@ -173,9 +722,9 @@ func NewLockFile(stewLockFilePath, userOS, userArch string) (LockFile, error) {
 	return lockFile, nil
 }
 
-// DeleteAssetAndBinary will delete the asset from the ~/.stew/pkg path and delete the binary from the ~/.stew/bin path
-func DeleteAssetAndBinary(stewPkgPath, stewBinPath, asset, binary string) error {
-	assetPath := filepath.Join(stewPkgPath, asset)
+// DeleteAssetAndBinary will delete the asset from the ~/.stew/assets path and delete the binary from the ~/.stew/bin path
+func DeleteAssetAndBinary(stewAssetPath, stewBinPath, asset, binary string) error {
+	assetPath := filepath.Join(stewAssetPath, asset)
 	binPath := filepath.Join(stewBinPath, binary)
 	err := os.RemoveAll(assetPath)
 	if err != nil {
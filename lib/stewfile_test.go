@@ -8,8 +8,9 @@ import (
 )
 
 var testLockfile LockFile = LockFile{
-	Os:   "darwin",
-	Arch: "arm64",
+	SchemaVersion: LockFileSchemaVersion,
+	Os:            "darwin",
+	Arch:          "arm64",
 	Packages: []PackageData{
 		{
 			Source: "github",
@@ -41,18 +42,34 @@ var testLockfile LockFile = LockFile{
 	},
 }
 
-var testStewfileContents string = `junegunn/fzf@0.29.0
+var testStewfileContents string = `# Tools for everyone
+junegunn/fzf@0.29.0
+
 https://github.com/sharkdp/hyperfine/releases/download/v1.12.0/hyperfine-v1.12.0-x86_64-apple-darwin.tar.gz
-marwanhawari/ppath@v0.0.3
+  marwanhawari/ppath@v0.0.3   # pretty print the PATH
 `
 
-var testStewfileSlice []string = []string{
-	"junegunn/fzf@0.29.0",
-	"https://github.com/sharkdp/hyperfine/releases/download/v1.12.0/hyperfine-v1.12.0-x86_64-apple-darwin.tar.gz",
-	"marwanhawari/ppath@v0.0.3",
+var testStewfileSlice []PackageData = []PackageData{
+	{
+		Source: "github",
+		Owner:  "junegunn",
+		Repo:   "fzf",
+		Tag:    "0.29.0",
+	},
+	{
+		Source: "other",
+		URL:    "https://github.com/sharkdp/hyperfine/releases/download/v1.12.0/hyperfine-v1.12.0-x86_64-apple-darwin.tar.gz",
+	},
+	{
+		Source: "github",
+		Owner:  "marwanhawari",
+		Repo:   "ppath",
+		Tag:    "v0.0.3",
+	},
 }
 
 var testStewLockFileContents string = `{
+	"schemaVersion": 2,
 	"os": "darwin",
 	"arch": "arm64",
 	"packages": [
@@ -63,7 +80,9 @@ var testStewLockFileContents string = `{
 		"tag": "v2.4.0",
 		"asset": "gh_2.4.0_macOS_amd64.tar.gz",
 		"binary": "gh",
-		"url": "https://github.com/cli/cli/releases/download/v2.4.0/gh_2.4.0_macOS_amd64.tar.gz"
+		"url": "https://github.com/cli/cli/releases/download/v2.4.0/gh_2.4.0_macOS_amd64.tar.gz",
+		"groups": null,
+		"host": ""
 	},
 	{
 		"source": "github",
@@ -72,7 +91,22 @@ var testStewLockFileContents string = `{
 		"tag": "0.29.0",
 		"asset": "fzf-0.29.0-darwin_arm64.zip",
 		"binary": "fzf",
-		"url": "https://github.com/junegunn/fzf/releases/download/0.29.0/fzf-0.29.0-darwin_arm64.zip"
+		"url": "https://github.com/junegunn/fzf/releases/download/0.29.0/fzf-0.29.0-darwin_arm64.zip",
+		"groups": null,
+		"host": "",
+		"binarySha256": "0e7d5a40a7f0dcd0c2f1a3b9c51bd7c1e8d5e6b8f7a0a3f3c0b2b6e3c41f2a9d",
+		"platforms": {
+			"darwin/arm64": {
+				"asset": "fzf-0.29.0-darwin_arm64.zip",
+				"url": "https://github.com/junegunn/fzf/releases/download/0.29.0/fzf-0.29.0-darwin_arm64.zip",
+				"sha256": "b9a8d5d3c4ba0a1e3a8ad1b8eb9c6b0f5b1e3d1c4e0b2d7f8a9c3e6d5b4a7f12"
+			},
+			"linux/amd64": {
+				"asset": "fzf-0.29.0-linux_amd64.tar.gz",
+				"url": "https://github.com/junegunn/fzf/releases/download/0.29.0/fzf-0.29.0-linux_amd64.tar.gz",
+				"sha256": "3c2f4e1d8a9b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d"
+			}
+		}
 	},
 	{
 		"source": "other",
@@ -81,16 +115,52 @@ var testStewLockFileContents string = `{
 		"tag": "",
 		"asset": "hyperfine-v1.12.0-x86_64-apple-darwin.tar.gz",
 		"binary": "hyperfine",
-		"url": "https://github.com/sharkdp/hyperfine/releases/download/v1.12.0/hyperfine-v1.12.0-x86_64-apple-darwin.tar.gz"
+		"url": "https://github.com/sharkdp/hyperfine/releases/download/v1.12.0/hyperfine-v1.12.0-x86_64-apple-darwin.tar.gz",
+		"groups": null,
+		"host": ""
 	}
 	]
 }
 `
 
-var testStewLockFileSlice []string = []string{
-	"cli/cli@v2.4.0",
-	"junegunn/fzf@0.29.0",
-	"https://github.com/sharkdp/hyperfine/releases/download/v1.12.0/hyperfine-v1.12.0-x86_64-apple-darwin.tar.gz",
+var testStewLockFilePackages []PackageData = []PackageData{
+	{
+		Source: "github",
+		Owner:  "cli",
+		Repo:   "cli",
+		Tag:    "v2.4.0",
+		Asset:  "gh_2.4.0_macOS_amd64.tar.gz",
+		Binary: "gh",
+		URL:    "https://github.com/cli/cli/releases/download/v2.4.0/gh_2.4.0_macOS_amd64.tar.gz",
+	},
+	{
+		Source:       "github",
+		Owner:        "junegunn",
+		Repo:         "fzf",
+		Tag:          "0.29.0",
+		Asset:        "fzf-0.29.0-darwin_arm64.zip",
+		Binary:       "fzf",
+		URL:          "https://github.com/junegunn/fzf/releases/download/0.29.0/fzf-0.29.0-darwin_arm64.zip",
+		BinarySHA256: "0e7d5a40a7f0dcd0c2f1a3b9c51bd7c1e8d5e6b8f7a0a3f3c0b2b6e3c41f2a9d",
+		Platforms: map[string]PlatformData{
+			"darwin/arm64": {
+				Asset:  "fzf-0.29.0-darwin_arm64.zip",
+				URL:    "https://github.com/junegunn/fzf/releases/download/0.29.0/fzf-0.29.0-darwin_arm64.zip",
+				SHA256: "b9a8d5d3c4ba0a1e3a8ad1b8eb9c6b0f5b1e3d1c4e0b2d7f8a9c3e6d5b4a7f12",
+			},
+			"linux/amd64": {
+				Asset:  "fzf-0.29.0-linux_amd64.tar.gz",
+				URL:    "https://github.com/junegunn/fzf/releases/download/0.29.0/fzf-0.29.0-linux_amd64.tar.gz",
+				SHA256: "3c2f4e1d8a9b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d",
+			},
+		},
+	},
+	{
+		Source: "other",
+		Asset:  "hyperfine-v1.12.0-x86_64-apple-darwin.tar.gz",
+		Binary: "hyperfine",
+		URL:    "https://github.com/sharkdp/hyperfine/releases/download/v1.12.0/hyperfine-v1.12.0-x86_64-apple-darwin.tar.gz",
+	},
 }
 
 func Test_readLockFileJSON(t *testing.T) {
@@ -229,7 +299,7 @@ func TestRemovePackage(t *testing.T) {
 func TestReadStewfileContents(t *testing.T) {
 	tests := []struct {
 		name    string
-		want    []string
+		want    []PackageData
 		wantErr bool
 	}{
 		{
@@ -263,12 +333,12 @@ func TestReadStewfileContents(t *testing.T) {
 func TestReadStewLockFileContents(t *testing.T) {
 	tests := []struct {
 		name    string
-		want    []string
+		want    []PackageData
 		wantErr bool
 	}{
 		{
 			name:    "test1",
-			want:    testStewLockFileSlice,
+			want:    testStewLockFilePackages,
 			wantErr: false,
 		},
 	}
@@ -394,6 +464,7 @@ func TestNewSystemInfo(t *testing.T) {
 				StewPath:         tempDir,
 				StewBinPath:      filepath.Join(tempDir, "bin"),
 				StewPkgPath:      filepath.Join(tempDir, "pkg"),
+				StewAssetPath:    filepath.Join(tempDir, "assets"),
 				StewLockFilePath: filepath.Join(tempDir, "Stewfile.lock.json"),
 				StewTmpPath:      filepath.Join(tempDir, "tmp"),
 			}
@@ -463,3 +534,684 @@ func TestDeleteAssetAndBinary(t *testing.T) {
 		})
 	}
 }
+
+func TestParsePlatform(t *testing.T) {
+	tests := []struct {
+		name     string
+		platform string
+		wantOS   string
+		wantArch string
+		wantErr  bool
+	}{
+		{
+			name:     "test1",
+			platform: "linux/arm64",
+			wantOS:   "linux",
+			wantArch: "arm64",
+			wantErr:  false,
+		},
+		{
+			name:     "test2",
+			platform: "linux",
+			wantErr:  true,
+		},
+		{
+			name:     "test3",
+			platform: "linux/sparc",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotOS, gotArch, err := ParsePlatform(tt.platform)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParsePlatform() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotOS != tt.wantOS || gotArch != tt.wantArch {
+				t.Errorf("ParsePlatform() = %v, %v, want %v, %v", gotOS, gotArch, tt.wantOS, tt.wantArch)
+			}
+		})
+	}
+}
+
+func TestFindPlatformData(t *testing.T) {
+	pkg := testLockfile.Packages[2]
+	pkg.Platforms = map[string]PlatformData{
+		"linux/amd64": {
+			Asset:  "ppath-v0.0.3-linux-amd64.tar.gz",
+			URL:    "https://github.com/marwanhawari/ppath/releases/download/v0.0.3/ppath-v0.0.3-linux-amd64.tar.gz",
+			SHA256: "f14515efd10b46142f50cb68fe727fa7c65dfa2e7b2d5f2212f4f417c2e9828d",
+		},
+	}
+	tests := []struct {
+		name      string
+		userOS    string
+		userArch  string
+		want      PlatformData
+		wantFound bool
+	}{
+		{
+			name:      "test1",
+			userOS:    "linux",
+			userArch:  "amd64",
+			want:      pkg.Platforms["linux/amd64"],
+			wantFound: true,
+		},
+		{
+			name:      "test2",
+			userOS:    "darwin",
+			userArch:  "arm64",
+			want:      PlatformData{Asset: pkg.Asset, URL: pkg.URL},
+			wantFound: true,
+		},
+		{
+			name:      "test3",
+			userOS:    "windows",
+			userArch:  "amd64",
+			want:      PlatformData{},
+			wantFound: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotFound := FindPlatformData(testLockfile, pkg, tt.userOS, tt.userArch)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindPlatformData() got = %v, want %v", got, tt.want)
+			}
+			if gotFound != tt.wantFound {
+				t.Errorf("FindPlatformData() gotFound = %v, want %v", gotFound, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestFindFrozenPlatformData(t *testing.T) {
+	pkg := testLockfile.Packages[2]
+	pkg.Platforms = map[string]PlatformData{
+		"linux/amd64": {
+			Asset:  "ppath-v0.0.3-linux-amd64.tar.gz",
+			URL:    "https://github.com/marwanhawari/ppath/releases/download/v0.0.3/ppath-v0.0.3-linux-amd64.tar.gz",
+			SHA256: "f14515efd10b46142f50cb68fe727fa7c65dfa2e7b2d5f2212f4f417c2e9828d",
+		},
+	}
+	tests := []struct {
+		name     string
+		userOS   string
+		userArch string
+		want     PlatformData
+		wantErr  bool
+	}{
+		{
+			name:     "test1",
+			userOS:   "linux",
+			userArch: "amd64",
+			want:     pkg.Platforms["linux/amd64"],
+			wantErr:  false,
+		},
+		{
+			name:     "test2",
+			userOS:   "darwin",
+			userArch: "arm64",
+			want:     PlatformData{},
+			wantErr:  true,
+		},
+		{
+			name:     "test3",
+			userOS:   "windows",
+			userArch: "amd64",
+			want:     PlatformData{},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindFrozenPlatformData(testLockfile, pkg, tt.userOS, tt.userArch)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FindFrozenPlatformData() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindFrozenPlatformData() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateFrozenLockFile(t *testing.T) {
+	tests := []struct {
+		name             string
+		stewfilePackages []PackageData
+		selectedGroups   []string
+		wantErr          bool
+	}{
+		{
+			name: "test1",
+			stewfilePackages: []PackageData{
+				{Source: "github", Owner: "junegunn", Repo: "fzf", Tag: "0.29.0"},
+				{Source: "other", URL: testLockfile.Packages[1].URL},
+				{Source: "github", Owner: "marwanhawari", Repo: "ppath"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "test2",
+			stewfilePackages: []PackageData{
+				{Source: "github", Owner: "junegunn", Repo: "fzf", Tag: "0.30.0"},
+				{Source: "other", URL: testLockfile.Packages[1].URL},
+				{Source: "github", Owner: "marwanhawari", Repo: "ppath"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "test3",
+			stewfilePackages: []PackageData{
+				{Source: "github", Owner: "junegunn", Repo: "fzf"},
+				{Source: "other", URL: testLockfile.Packages[1].URL},
+			},
+			wantErr: true,
+		},
+		{
+			name: "test4",
+			stewfilePackages: []PackageData{
+				{Source: "github", Owner: "junegunn", Repo: "fzf"},
+				{Source: "other", URL: testLockfile.Packages[1].URL},
+				{Source: "github", Owner: "marwanhawari", Repo: "ppath", Asset: "ppath-v0.0.3-linux-amd64.tar.gz"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "test5",
+			stewfilePackages: []PackageData{
+				{Source: "github", Owner: "junegunn", Repo: "fzf"},
+				{Source: "other", URL: testLockfile.Packages[1].URL},
+				{Source: "github", Owner: "marwanhawari", Repo: "ppath"},
+				{Source: "github", Owner: "containers", Repo: "podman", OnlyOS: []string{"linux"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "test6",
+			stewfilePackages: []PackageData{
+				{Source: "github", Owner: "junegunn", Repo: "fzf"},
+				{Source: "other", URL: testLockfile.Packages[1].URL},
+				{Source: "github", Owner: "marwanhawari", Repo: "ppath"},
+				{Source: "github", Owner: "kubernetes", Repo: "kubectl", StewfileGroups: []string{"k8s"}},
+			},
+			selectedGroups: []string{"core"},
+			wantErr:        false,
+		},
+		{
+			name: "test7",
+			stewfilePackages: []PackageData{
+				{Source: "github", Owner: "junegunn", Repo: "fzf"},
+				{Source: "other", URL: testLockfile.Packages[1].URL},
+				{Source: "github", Owner: "marwanhawari", Repo: "ppath"},
+				{Source: "github", Owner: "kubernetes", Repo: "kubectl", StewfileGroups: []string{"k8s"}},
+			},
+			selectedGroups: []string{"k8s"},
+			wantErr:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateFrozenLockFile(tt.stewfilePackages, testLockfile, testLockfile.Os, testLockfile.Arch, tt.selectedGroups)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateFrozenLockFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFormatStewfileLine(t *testing.T) {
+	type args struct {
+		pkg          PackageData
+		includeTag   bool
+		includeAsset bool
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "test1",
+			args: args{
+				pkg:          testLockfile.Packages[0],
+				includeTag:   true,
+				includeAsset: true,
+			},
+			want: "junegunn/fzf@0.29.0#fzf-0.29.0-darwin_arm64.zip",
+		},
+		{
+			name: "test2",
+			args: args{
+				pkg:          testLockfile.Packages[0],
+				includeTag:   true,
+				includeAsset: false,
+			},
+			want: "junegunn/fzf@0.29.0",
+		},
+		{
+			name: "test3",
+			args: args{
+				pkg:          testLockfile.Packages[0],
+				includeTag:   false,
+				includeAsset: true,
+			},
+			want: "junegunn/fzf",
+		},
+		{
+			name: "test4",
+			args: args{
+				pkg:          testLockfile.Packages[1],
+				includeTag:   true,
+				includeAsset: true,
+			},
+			want: testLockfile.Packages[1].URL,
+		},
+		{
+			name: "test5",
+			args: args{
+				pkg: PackageData{
+					Source: "gitea",
+					Owner:  "abs3nt",
+					Repo:   "gspot",
+					Tag:    "v0.0.22",
+					Asset:  "gspot_Linux_x86_64.tar.gz",
+					Host:   "git.asdf.cafe",
+				},
+				includeTag:   true,
+				includeAsset: true,
+			},
+			want: "abs3nt/gspot@v0.0.22#gspot_Linux_x86_64.tar.gz?source=gitea&host=git.asdf.cafe",
+		},
+		{
+			name: "test6",
+			args: args{
+				pkg: PackageData{
+					Source:    "github",
+					Owner:     "junegunn",
+					Repo:      "fzf",
+					OnlyOS:    []string{"darwin", "linux"},
+					Overrides: map[string]PlatformOverride{"linux/arm64": {Asset: "fzf-linux_arm64.tar.gz"}},
+				},
+				includeTag:   true,
+				includeAsset: true,
+			},
+			want: "junegunn/fzf?os=darwin,linux&asset.linux/arm64=fzf-linux_arm64.tar.gz",
+		},
+		{
+			name: "test7",
+			args: args{
+				pkg: PackageData{
+					Source: "other",
+					URL:    "https://dl.k8s.io/release/v1.29.0/bin/darwin/arm64/kubectl",
+					OnlyOS: []string{"darwin"},
+				},
+				includeTag:   true,
+				includeAsset: true,
+			},
+			want: "https://dl.k8s.io/release/v1.29.0/bin/darwin/arm64/kubectl?os=darwin",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatStewfileLine(tt.args.pkg, tt.args.includeTag, tt.args.includeAsset); got != tt.want {
+				t.Errorf("FormatStewfileLine() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseStewfileLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    PackageData
+		wantErr bool
+	}{
+		{
+			name:    "test1",
+			line:    "abs3nt/gspot@v0.0.22#gspot_Linux_x86_64.tar.gz?source=gitea&host=git.asdf.cafe",
+			want:    PackageData{Source: "gitea", Owner: "abs3nt", Repo: "gspot", Tag: "v0.0.22", Asset: "gspot_Linux_x86_64.tar.gz", Host: "git.asdf.cafe"},
+			wantErr: false,
+		},
+		{
+			name:    "test2",
+			line:    "group/subgroup/project?source=gitlab&host=gitlab.example.com",
+			want:    PackageData{Source: "gitlab", Groups: []string{"group", "subgroup"}, Repo: "project", Host: "gitlab.example.com"},
+			wantErr: false,
+		},
+		{
+			name:    "test3",
+			line:    "junegunn",
+			wantErr: true,
+		},
+		{
+			name:    "test4",
+			line:    "junegunn/fzf?source",
+			wantErr: true,
+		},
+		{
+			name: "test10",
+			line: "junegunn/fzf?os=darwin,linux&arch=arm64&asset.linux/arm64=fzf-linux_arm64.tar.gz",
+			want: PackageData{
+				Source:    "github",
+				Owner:     "junegunn",
+				Repo:      "fzf",
+				OnlyOS:    []string{"darwin", "linux"},
+				OnlyArch:  []string{"arm64"},
+				Overrides: map[string]PlatformOverride{"linux/arm64": {Asset: "fzf-linux_arm64.tar.gz"}},
+			},
+		},
+		{
+			name:    "test11",
+			line:    "junegunn/fzf?os=macos",
+			wantErr: true,
+		},
+		{
+			name:    "test12",
+			line:    "junegunn/fzf?asset.linux=fzf.tar.gz",
+			wantErr: true,
+		},
+		{
+			name:    "test5",
+			line:    "junegunn/fzf?source=bitbucket",
+			wantErr: true,
+		},
+		{
+			name:    "test6",
+			line:    "junegunn/fzf?color=blue",
+			wantErr: true,
+		},
+		{
+			name:    "test7",
+			line:    "abs3nt/gspot?source=gitea&host=git asdf",
+			wantErr: true,
+		},
+		{
+			name:    "test8",
+			line:    "abs3nt/gspot?source=gitea",
+			wantErr: true,
+		},
+		{
+			name:    "test9",
+			line:    "junegunn/fzf@#fzf.zip",
+			wantErr: true,
+		},
+		{
+			name: "test13",
+			line: "https://dl.k8s.io/release/v1.29.0/bin/darwin/arm64/kubectl?os=darwin&arch=arm64",
+			want: PackageData{
+				Source:   "other",
+				URL:      "https://dl.k8s.io/release/v1.29.0/bin/darwin/arm64/kubectl",
+				OnlyOS:   []string{"darwin"},
+				OnlyArch: []string{"arm64"},
+			},
+		},
+		{
+			name: "test14",
+			line: "https://example.com/download?file=tool&version=1.0",
+			want: PackageData{Source: "other", URL: "https://example.com/download?file=tool&version=1.0"},
+		},
+		{
+			name:    "test15",
+			line:    "https://example.com/tool?os=macos",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStewfileLine(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseStewfileLine() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseStewfileLine() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateStewfile(t *testing.T) {
+	testStewfilePath := filepath.Join(t.TempDir(), "Stewfile")
+	contents := `junegunn/fzf
+
+# comment
+junegunn
+junegunn/fzf@0.29.0
+`
+	err := os.WriteFile(testStewfilePath, []byte(contents), 0644)
+	if err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	packages, errs, err := ValidateStewfile(testStewfilePath)
+	if err != nil {
+		t.Fatalf("ValidateStewfile() error = %v", err)
+	}
+	if len(packages) != 1 {
+		t.Errorf("ValidateStewfile() got %v packages, want 1", len(packages))
+	}
+	wantLines := []int{4, 5}
+	if len(errs) != len(wantLines) {
+		t.Fatalf("ValidateStewfile() got %v errors, want %v", len(errs), len(wantLines))
+	}
+	for i, err := range errs {
+		parseErr, ok := err.(StewfileParseError)
+		if !ok {
+			t.Errorf("ValidateStewfile() error %v is not a StewfileParseError", err)
+			continue
+		}
+		if parseErr.Line != wantLines[i] {
+			t.Errorf("ValidateStewfile() error on line %v, want line %v", parseErr.Line, wantLines[i])
+		}
+	}
+}
+
+func TestValidateStewfile_groups(t *testing.T) {
+	testStewfilePath := filepath.Join(t.TempDir(), "Stewfile")
+	contents := `junegunn/fzf
+
+[group.k8s]
+kubernetes/kubectl
+helm/helm@v3.14.0
+
+[group.infra] # shared with k8s
+helm/helm@v3.14.0
+hashicorp/terraform
+
+[group.k8s]
+helm/helm@v3.13.0
+[groups]
+`
+	err := os.WriteFile(testStewfilePath, []byte(contents), 0644)
+	if err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	packages, errs, err := ValidateStewfile(testStewfilePath)
+	if err != nil {
+		t.Fatalf("ValidateStewfile() error = %v", err)
+	}
+	wantGroups := [][]string{nil, {"k8s"}, {"k8s", "infra"}, {"infra"}}
+	if len(packages) != len(wantGroups) {
+		t.Fatalf("ValidateStewfile() got %v packages, want %v", len(packages), len(wantGroups))
+	}
+	for i, pkg := range packages {
+		if !reflect.DeepEqual(pkg.StewfileGroups, wantGroups[i]) {
+			t.Errorf("ValidateStewfile() package %v has groups %v, want %v", pkg.Repo, pkg.StewfileGroups, wantGroups[i])
+		}
+	}
+	if len(errs) != 2 {
+		t.Errorf("ValidateStewfile() got %v errors, want 2: %v", len(errs), errs)
+	}
+}
+
+func TestFilterStewfileGroups(t *testing.T) {
+	packages := []PackageData{
+		{Source: "github", Owner: "junegunn", Repo: "fzf"},
+		{Source: "github", Owner: "kubernetes", Repo: "kubectl", StewfileGroups: []string{"k8s"}},
+		{Source: "github", Owner: "helm", Repo: "helm", StewfileGroups: []string{"k8s", "infra"}},
+		{Source: "github", Owner: "hashicorp", Repo: "terraform", StewfileGroups: []string{"infra"}},
+	}
+	tests := []struct {
+		name           string
+		selectedGroups []string
+		want           []string
+		wantErr        bool
+	}{
+		{
+			name:           "test1",
+			selectedGroups: nil,
+			want:           []string{"fzf", "kubectl", "helm", "terraform"},
+		},
+		{
+			name:           "test2",
+			selectedGroups: []string{"k8s"},
+			want:           []string{"fzf", "kubectl", "helm"},
+		},
+		{
+			name:           "test3",
+			selectedGroups: []string{"infra"},
+			want:           []string{"fzf", "helm", "terraform"},
+		},
+		{
+			name:           "test4",
+			selectedGroups: []string{"data"},
+			wantErr:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FilterStewfileGroups(packages, tt.selectedGroups)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FilterStewfileGroups() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			gotRepos := []string{}
+			for _, pkg := range got {
+				gotRepos = append(gotRepos, pkg.Repo)
+			}
+			if !reflect.DeepEqual(gotRepos, tt.want) {
+				t.Errorf("FilterStewfileGroups() = %v, want %v", gotRepos, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesPlatform(t *testing.T) {
+	tests := []struct {
+		name       string
+		pkg        PackageData
+		targetOS   string
+		targetArch string
+		want       bool
+	}{
+		{
+			name:       "test1",
+			pkg:        PackageData{},
+			targetOS:   "linux",
+			targetArch: "amd64",
+			want:       true,
+		},
+		{
+			name:       "test2",
+			pkg:        PackageData{OnlyOS: []string{"darwin"}},
+			targetOS:   "linux",
+			targetArch: "amd64",
+			want:       false,
+		},
+		{
+			name:       "test3",
+			pkg:        PackageData{OnlyOS: []string{"darwin", "linux"}, OnlyArch: []string{"amd64"}},
+			targetOS:   "linux",
+			targetArch: "amd64",
+			want:       true,
+		},
+		{
+			name:       "test4",
+			pkg:        PackageData{OnlyArch: []string{"amd64"}},
+			targetOS:   "linux",
+			targetArch: "arm64",
+			want:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchesPlatform(tt.pkg, tt.targetOS, tt.targetArch); got != tt.want {
+				t.Errorf("MatchesPlatform() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterPlatforms(t *testing.T) {
+	pkg := PackageData{OnlyOS: []string{"linux"}}
+	got := FilterPlatforms(pkg, []string{"darwin/arm64", "linux/amd64", "linux/arm64"})
+	want := []string{"linux/amd64", "linux/arm64"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FilterPlatforms() = %v, want %v", got, want)
+	}
+}
+
+func Test_readLockFileJSON_newerSchema(t *testing.T) {
+	lockFilePath := filepath.Join(t.TempDir(), "Stewfile.lock.json")
+	err := os.WriteFile(lockFilePath, []byte(`{"schemaVersion": 99, "os": "darwin", "arch": "arm64", "packages": []}`), 0644)
+	if err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	_, err = readLockFileJSON(lockFilePath)
+	if _, ok := err.(UnsupportedLockFileSchemaError); !ok {
+		t.Errorf("readLockFileJSON() error = %v, want an UnsupportedLockFileSchemaError", err)
+	}
+}
+
+func TestMigrateLockFile(t *testing.T) {
+	lockFile := LockFile{
+		Os:   "linux",
+		Arch: "amd64",
+		Packages: []PackageData{
+			{Owner: "junegunn", Repo: "fzf", Binary: "fzf"},
+			{Source: "gitlab", Owner: "group/subgroup", Repo: "project", Binary: "project", Host: "gitlab.example.com"},
+		},
+	}
+	want := LockFile{
+		SchemaVersion: LockFileSchemaVersion,
+		Os:            "linux",
+		Arch:          "amd64",
+		Packages: []PackageData{
+			{Source: "github", Owner: "junegunn", Repo: "fzf", Binary: "fzf"},
+			{Source: "gitlab", Owner: "group/subgroup", Groups: []string{"group", "subgroup"}, Repo: "project", Binary: "project", Host: "gitlab.example.com"},
+		},
+	}
+	if got := MigrateLockFile(lockFile); !reflect.DeepEqual(got, want) {
+		t.Errorf("MigrateLockFile() = %v, want %v", got, want)
+	}
+}
+
+func TestSortLockFilePackages(t *testing.T) {
+	packages := []PackageData{
+		{Source: "github", Owner: "sharkdp", Repo: "fd", Binary: "fd"},
+		{Source: "github", Owner: "junegunn", Repo: "fzf"},
+		{Source: "github", Owner: "burntsushi", Repo: "ripgrep", Binary: "rg"},
+		{Source: "github", Owner: "cli", Repo: "cli"},
+	}
+	want := []string{"cli/cli", "junegunn/fzf", "sharkdp/fd", "burntsushi/ripgrep"}
+	got := []string{}
+	for _, pkg := range SortLockFilePackages(packages) {
+		got = append(got, FormatStewfileLine(pkg, false, false))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SortLockFilePackages() = %v, want %v", got, want)
+	}
+	if packages[0].Repo != "fd" {
+		t.Errorf("SortLockFilePackages() modified the input packages")
+	}
+}
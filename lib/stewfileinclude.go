@@ -0,0 +1,134 @@
+package stew
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IsRemoteStewfile checks if a Stewfile location is an HTTP(S) URL instead of a local path
+func IsRemoteStewfile(location string) bool {
+	return strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "http://")
+}
+
+// StewfileLockFilePath returns the path of the lockfile that belongs to a Stewfile. The lockfile of a remote
+// Stewfile is kept in the current directory.
+func StewfileLockFilePath(stewfilePath string) string {
+	if IsRemoteStewfile(stewfilePath) {
+		return "Stewfile.lock.json"
+	}
+	return filepath.Join(filepath.Dir(stewfilePath), "Stewfile.lock.json")
+}
+
+// readStewfileLocation reads the contents of a Stewfile from a path or URL
+func readStewfileLocation(location string) ([]byte, error) {
+	if !IsRemoteStewfile(location) {
+		return os.ReadFile(location)
+	}
+
+	res, err := http.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, NonZeroStatusCodeError{StatusCode: res.StatusCode}
+	}
+	return io.ReadAll(res.Body)
+}
+
+func sha256Hex(contents []byte) string {
+	digest := sha256.Sum256(contents)
+	return hex.EncodeToString(digest[:])
+}
+
+// parseStewfileInclude parses the part of an include directive after the include keyword [Ex: ./base/Stewfile sha256=abc]
+func parseStewfileInclude(include string) (string, string, error) {
+	fields := strings.Fields(include)
+	switch len(fields) {
+	case 1:
+		return fields[0], "", nil
+	case 2:
+		expectedSHA256, found := strings.CutPrefix(fields[1], "sha256=")
+		if !found || expectedSHA256 == "" {
+			return "", "", fmt.Errorf("the include option %v must be written as sha256=<digest>", fields[1])
+		}
+		return fields[0], expectedSHA256, nil
+	}
+	return "", "", errors.New("an include must be written as include <path or URL> [sha256=<digest>]")
+}
+
+// resolveStewfileInclude resolves an include relative to the Stewfile that contains it
+func resolveStewfileInclude(parent, include string) (string, error) {
+	if IsRemoteStewfile(include) || filepath.IsAbs(include) {
+		return include, nil
+	}
+	if IsRemoteStewfile(parent) {
+		parentURL, err := url.Parse(parent)
+		if err != nil {
+			return "", err
+		}
+		includeURL, err := url.Parse(include)
+		if err != nil {
+			return "", err
+		}
+		return parentURL.ResolveReference(includeURL).String(), nil
+	}
+	return filepath.Join(filepath.Dir(parent), include), nil
+}
+
+// includeStewfile parses a Stewfile that is included by another one
+func includeStewfile(parent, include, expectedSHA256 string, includeStack []string) ([]PackageData, []error, error) {
+	if include == "" {
+		return nil, nil, errors.New("the include is missing a path or URL")
+	}
+	location, err := resolveStewfileInclude(parent, include)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, found := Contains(includeStack, location); found {
+		return nil, nil, fmt.Errorf("%v includes itself through %v", location, strings.Join(includeStack, " -> "))
+	}
+	packages, errs, err := parseStewfileLocation(location, expectedSHA256, includeStack, true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not include %v: %w", include, err)
+	}
+	return packages, errs, nil
+}
+
+// stewfileMerge collects the packages of a Stewfile and its includes. A later entry for the same package replaces the
+// earlier one but keeps its position.
+type stewfileMerge struct {
+	packages []PackageData
+	indexes  map[string]int
+}
+
+func newStewfileMerge() *stewfileMerge {
+	return &stewfileMerge{packages: []PackageData{}, indexes: map[string]int{}}
+}
+
+func (m *stewfileMerge) add(p PackageData) {
+	key := FormatStewfileLine(p, false, false)
+	if index, found := m.indexes[key]; found {
+		m.packages[index] = p
+		return
+	}
+	m.indexes[key] = len(m.packages)
+	m.packages = append(m.packages, p)
+}
+
+// stewfileName returns the file name of a Stewfile path or URL
+func stewfileName(location string) string {
+	if parsedURL, err := url.Parse(location); err == nil && IsRemoteStewfile(location) {
+		return path.Base(parsedURL.Path)
+	}
+	return filepath.Base(location)
+}
@@ -0,0 +1,160 @@
+package stew
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseStewfileInclude(t *testing.T) {
+	tests := []struct {
+		name       string
+		include    string
+		wantPath   string
+		wantSHA256 string
+		wantErr    bool
+	}{
+		{
+			name:     "test1",
+			include:  "./base/Stewfile",
+			wantPath: "./base/Stewfile",
+		},
+		{
+			name:       "test2",
+			include:    "https://example.com/Stewfile sha256=abc123",
+			wantPath:   "https://example.com/Stewfile",
+			wantSHA256: "abc123",
+		},
+		{
+			name:    "test3",
+			include: "https://example.com/Stewfile abc123",
+			wantErr: true,
+		},
+		{
+			name:    "test4",
+			include: "a b c",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPath, gotSHA256, err := parseStewfileInclude(tt.include)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseStewfileInclude() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotPath != tt.wantPath || gotSHA256 != tt.wantSHA256 {
+				t.Errorf("parseStewfileInclude() = %v, %v, want %v, %v", gotPath, gotSHA256, tt.wantPath, tt.wantSHA256)
+			}
+		})
+	}
+}
+
+func TestResolveStewfileInclude(t *testing.T) {
+	tests := []struct {
+		name    string
+		parent  string
+		include string
+		want    string
+	}{
+		{
+			name:    "test1",
+			parent:  filepath.Join("dotfiles", "Stewfile"),
+			include: "base.Stewfile",
+			want:    filepath.Join("dotfiles", "base.Stewfile"),
+		},
+		{
+			name:    "test2",
+			parent:  "https://example.com/stew/team/Stewfile",
+			include: "../base/Stewfile",
+			want:    "https://example.com/stew/base/Stewfile",
+		},
+		{
+			name:    "test3",
+			parent:  filepath.Join("dotfiles", "Stewfile"),
+			include: "https://example.com/Stewfile",
+			want:    "https://example.com/Stewfile",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveStewfileInclude(tt.parent, tt.include)
+			if err != nil {
+				t.Fatalf("resolveStewfileInclude() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveStewfileInclude() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadStewfileContents_includes(t *testing.T) {
+	const remoteStewfile = "BurntSushi/ripgrep\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/Stewfile" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(remoteStewfile))
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"base.Stewfile": "junegunn/fzf\ninclude " + server.URL + "/Stewfile sha256=" + sha256Hex([]byte(remoteStewfile)) + "\nsharkdp/fd\n",
+		"team.toml":     "[[include]]\npath = \"base.Stewfile\"\n\n[packages.fd]\nrepo = \"sharkdp/fd\"\ntag = \"v9.0.0\"\n",
+		"Stewfile":      "include team.toml\njunegunn/fzf@0.29.0\n",
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(contents), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	got, err := ReadStewfileContents(filepath.Join(tempDir, "Stewfile"))
+	if err != nil {
+		t.Fatalf("ReadStewfileContents() error = %v", err)
+	}
+	want := []string{"junegunn/fzf@0.29.0", "BurntSushi/ripgrep", "sharkdp/fd@v9.0.0"}
+	gotLines := []string{}
+	for _, pkg := range got {
+		gotLines = append(gotLines, FormatStewfileLine(pkg, true, false))
+	}
+	if !reflect.DeepEqual(gotLines, want) {
+		t.Errorf("ReadStewfileContents() = %v, want %v", gotLines, want)
+	}
+
+	got, err = ReadStewfileContents(server.URL + "/Stewfile")
+	if err != nil {
+		t.Fatalf("ReadStewfileContents() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Repo != "ripgrep" {
+		t.Errorf("ReadStewfileContents() = %v, want the ripgrep package", got)
+	}
+}
+
+func TestValidateStewfile_includeErrors(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"Stewfile":       "include other.Stewfile\ninclude missing.Stewfile\ninclude base.Stewfile sha256=abc123\n",
+		"other.Stewfile": "include Stewfile\n",
+		"base.Stewfile":  "junegunn/fzf\n",
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(contents), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	_, errs, err := ValidateStewfile(filepath.Join(tempDir, "Stewfile"))
+	if err != nil {
+		t.Fatalf("ValidateStewfile() error = %v", err)
+	}
+	if len(errs) != 3 {
+		t.Errorf("ValidateStewfile() got %v errors, want 3: %v", len(errs), errs)
+	}
+}
@@ -0,0 +1,366 @@
+package stew
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// structuredStewfile is the layout of a structured (TOML) Stewfile
+type structuredStewfile struct {
+	Include  []structuredInclude          `toml:"include"`
+	Packages map[string]structuredPackage `toml:"packages"`
+	Group    map[string]structuredGroup   `toml:"group"`
+}
+
+// structuredInclude is an [[include]] table of a structured Stewfile
+type structuredInclude struct {
+	Path   string `toml:"path"`
+	SHA256 string `toml:"sha256,omitempty"`
+}
+
+// structuredGroup is a [group.<name>] table of a structured Stewfile
+type structuredGroup struct {
+	Packages []string `toml:"packages"`
+}
+
+// structuredPackage is a single [packages.<name>] table of a structured Stewfile
+type structuredPackage struct {
+	Repo         string                        `toml:"repo,omitempty"`
+	URL          string                        `toml:"url,omitempty"`
+	Source       string                        `toml:"source,omitempty"`
+	Host         string                        `toml:"host,omitempty"`
+	Tag          string                        `toml:"tag,omitempty"`
+	Constraint   string                        `toml:"constraint,omitempty"`
+	Channel      string                        `toml:"channel,omitempty"`
+	Asset        string                        `toml:"asset,omitempty"`
+	AssetPattern string                        `toml:"asset_pattern,omitempty"`
+	Binary       string                        `toml:"binary,omitempty"`
+	ExtraFiles   []string                      `toml:"extra_files,omitempty"`
+	OS           []string                      `toml:"os,omitempty"`
+	Arch         []string                      `toml:"arch,omitempty"`
+	Platforms    map[string]structuredPlatform `toml:"platforms,omitempty"`
+}
+
+// structuredPlatform is a [packages.<name>.platforms."<os>/<arch>"] table of a structured Stewfile
+type structuredPlatform struct {
+	Asset        string `toml:"asset,omitempty"`
+	AssetPattern string `toml:"asset_pattern,omitempty"`
+}
+
+var stewfileChannels = []string{"stable", "prerelease"}
+
+// IsStructuredStewfile checks if a Stewfile uses the structured TOML format instead of one entry per line
+func IsStructuredStewfile(stewfilePath string) bool {
+	return filepath.Ext(stewfileName(stewfilePath)) == ".toml"
+}
+
+// parseStructuredStewfile parses a TOML Stewfile. Packages are returned in the order they appear in the file.
+func parseStructuredStewfile(stewfilePath string, contents []byte, includeStack []string, resolveIncludes bool) ([]PackageData, []error, error) {
+	var stewfile structuredStewfile
+	metaData, err := toml.Decode(string(contents), &stewfile)
+	if err != nil {
+		var parseErr toml.ParseError
+		if errors.As(err, &parseErr) {
+			message := parseErr.Message
+			if message == "" {
+				message = parseErr.Error()
+			}
+			return []PackageData{}, []error{
+				StewfileParseError{Path: stewfilePath, Line: parseErr.Position.Line, Err: errors.New(message)},
+			}, nil
+		}
+		return []PackageData{}, []error{}, err
+	}
+
+	errs := []error{}
+	for _, key := range metaData.Undecoded() {
+		errs = append(errs, StewfileParseError{Path: stewfilePath, Err: fmt.Errorf("unknown key %v", key)})
+	}
+
+	packages := []PackageData{}
+	packageNames := map[string]string{}
+	packageIndexes := map[string]int{}
+	for _, key := range metaData.Keys() {
+		if len(key) != 2 || key[0] != "packages" {
+			continue
+		}
+		name := key[1]
+		p, err := structuredPackageToPackageData(stewfile.Packages[name])
+		if err != nil {
+			errs = append(errs, StewfileParseError{Path: stewfilePath, Err: fmt.Errorf("packages.%v: %w", name, err)})
+			continue
+		}
+		packageKey := FormatStewfileLine(p, false, false)
+		if previousName, found := packageNames[packageKey]; found {
+			errs = append(errs, StewfileParseError{
+				Path: stewfilePath,
+				Err:  fmt.Errorf("packages.%v: %v is already listed as packages.%v", name, packageKey, previousName),
+			})
+			continue
+		}
+		packageNames[packageKey] = name
+		packageIndexes[name] = len(packages)
+		packages = append(packages, p)
+	}
+
+	for _, key := range metaData.Keys() {
+		if len(key) != 2 || key[0] != "group" {
+			continue
+		}
+		group := key[1]
+		if !stewfileGroupRegex.MatchString(group) {
+			errs = append(errs, StewfileParseError{Path: stewfilePath, Err: fmt.Errorf("%v is not a valid group name", group)})
+			continue
+		}
+		for _, name := range stewfile.Group[group].Packages {
+			index, found := packageIndexes[name]
+			if !found {
+				errs = append(errs, StewfileParseError{Path: stewfilePath, Err: fmt.Errorf("group.%v: unknown package %v", group, name)})
+				continue
+			}
+			if _, inGroup := Contains(packages[index].StewfileGroups, group); !inGroup {
+				packages[index].StewfileGroups = append(packages[index].StewfileGroups, group)
+			}
+		}
+	}
+
+	// Included Stewfiles come first, so the packages of this Stewfile replace any of theirs
+	merged := newStewfileMerge()
+	for index, include := range stewfile.Include {
+		if !resolveIncludes {
+			break
+		}
+		includedPackages, includeErrs, err := includeStewfile(stewfilePath, include.Path, include.SHA256, includeStack)
+		if err != nil {
+			errs = append(errs, StewfileParseError{Path: stewfilePath, Err: fmt.Errorf("include[%v]: %w", index, err)})
+			continue
+		}
+		errs = append(errs, includeErrs...)
+		for _, p := range includedPackages {
+			merged.add(p)
+		}
+	}
+	for _, p := range packages {
+		merged.add(p)
+	}
+
+	return merged.packages, errs, nil
+}
+
+// structuredPackageToPackageData validates a package table and converts it into a PackageData
+func structuredPackageToPackageData(structured structuredPackage) (PackageData, error) {
+	if structured.URL != "" {
+		if structured.Repo != "" {
+			return PackageData{}, errors.New("only one of repo and url can be set")
+		}
+		p, err := parseStewfileLine(structured.URL)
+		if err != nil {
+			return PackageData{}, err
+		}
+		if p.Source != "other" {
+			return PackageData{}, fmt.Errorf("%v is not a valid URL", structured.URL)
+		}
+		if err := validatePlatformRestriction(structured.OS, structured.Arch); err != nil {
+			return PackageData{}, err
+		}
+		p.Binary = structured.Binary
+		p.ExtraFiles = structured.ExtraFiles
+		p.OnlyOS = structured.OS
+		p.OnlyArch = structured.Arch
+		return p, nil
+	}
+	if structured.Repo == "" {
+		return PackageData{}, errors.New("either repo or url must be set")
+	}
+
+	// Reuse the line parser so both formats validate sources, hosts and repos the same way
+	line := structured.Repo
+	options := []string{}
+	if structured.Source != "" {
+		options = append(options, "source="+structured.Source)
+	}
+	if structured.Host != "" {
+		options = append(options, "host="+structured.Host)
+	}
+	if len(options) > 0 {
+		line += "?" + strings.Join(options, "&")
+	}
+	p, err := parseStewfileLine(line)
+	if err != nil {
+		return PackageData{}, err
+	}
+	if p.Tag != "" {
+		return PackageData{}, errors.New("the tag must be set with the tag key instead of repo@tag")
+	}
+
+	if structured.Tag != "" && structured.Constraint != "" {
+		return PackageData{}, errors.New("only one of tag and constraint can be set")
+	}
+	if structured.Asset != "" && structured.AssetPattern != "" {
+		return PackageData{}, errors.New("only one of asset and asset_pattern can be set")
+	}
+	if structured.Constraint != "" {
+		if _, err := SatisfiesConstraint("0.0.0", structured.Constraint); err != nil {
+			return PackageData{}, err
+		}
+	}
+	if err := validateAssetPattern(structured.AssetPattern); err != nil {
+		return PackageData{}, err
+	}
+	if err := validatePlatformRestriction(structured.OS, structured.Arch); err != nil {
+		return PackageData{}, err
+	}
+
+	p.Channel = structured.Channel
+	if p.Channel == "" {
+		p.Channel = "stable"
+	}
+	if _, known := Contains(stewfileChannels, p.Channel); !known {
+		return PackageData{}, fmt.Errorf("unknown channel %v, expected one of %v", p.Channel, strings.Join(stewfileChannels, ", "))
+	}
+
+	p.Tag = structured.Tag
+	p.Constraint = structured.Constraint
+	p.Asset = structured.Asset
+	p.AssetPattern = structured.AssetPattern
+	p.Binary = structured.Binary
+	p.ExtraFiles = structured.ExtraFiles
+	p.OnlyOS = structured.OS
+	p.OnlyArch = structured.Arch
+
+	if len(structured.Platforms) > 0 {
+		p.Overrides = make(map[string]PlatformOverride, len(structured.Platforms))
+	}
+	for platform, override := range structured.Platforms {
+		if _, _, err := ParsePlatform(platform); err != nil {
+			return PackageData{}, fmt.Errorf("unknown platform %v", platform)
+		}
+		if override.Asset != "" && override.AssetPattern != "" {
+			return PackageData{}, fmt.Errorf("only one of asset and asset_pattern can be set for %v", platform)
+		}
+		if err := validateAssetPattern(override.AssetPattern); err != nil {
+			return PackageData{}, err
+		}
+		p.Overrides[platform] = PlatformOverride{Asset: override.Asset, AssetPattern: override.AssetPattern}
+	}
+
+	return p, nil
+}
+
+var bareTOMLKeyRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// tomlKey quotes a TOML key if it can't be written as a bare key
+func tomlKey(key string) string {
+	if bareTOMLKeyRegex.MatchString(key) {
+		return key
+	}
+	return fmt.Sprintf("%q", key)
+}
+
+func validateAssetPattern(assetPattern string) error {
+	if assetPattern == "" {
+		return nil
+	}
+	if _, err := regexp.Compile(assetPattern); err != nil {
+		return fmt.Errorf("the asset_pattern %v is not a valid regular expression", assetPattern)
+	}
+	return nil
+}
+
+// defaultConvertedPackageName names a converted URL package whose file name has no usable characters
+const defaultConvertedPackageName = "package"
+
+// uniquePackageName appends a number to a package name until it doesn't collide with a name that is already used
+func uniquePackageName(name string, usedNames map[string]bool) string {
+	uniqueName := name
+	for count := 2; usedNames[uniqueName]; count++ {
+		uniqueName = fmt.Sprintf("%v-%v", name, count)
+	}
+	return uniqueName
+}
+
+// ConvertStewfile converts Stewfile entries into the structured TOML format.
+// Each package is named after its repo, or the start of the file name for URLs.
+func ConvertStewfile(packages []PackageData) (string, error) {
+	var buffer bytes.Buffer
+	usedNames := map[string]bool{}
+	groupPackages := map[string][]string{}
+	for index, pkg := range packages {
+		structured := structuredPackage{
+			Tag:          pkg.Tag,
+			Constraint:   pkg.Constraint,
+			Asset:        pkg.Asset,
+			AssetPattern: pkg.AssetPattern,
+			Binary:       pkg.Binary,
+			ExtraFiles:   pkg.ExtraFiles,
+			OS:           pkg.OnlyOS,
+			Arch:         pkg.OnlyArch,
+		}
+		if pkg.Channel != "stable" {
+			structured.Channel = pkg.Channel
+		}
+		var name string
+		if pkg.Source == "other" {
+			structured.URL = pkg.URL
+			structured.Asset = ""
+			nameParts := strings.FieldsFunc(path.Base(pkg.URL), func(r rune) bool {
+				return r == '-' || r == '_' || r == '.' || r == '/'
+			})
+			name = defaultConvertedPackageName
+			if len(nameParts) > 0 {
+				name = nameParts[0]
+			}
+		} else {
+			structured.Repo = GetPackageOwner(pkg) + "/" + pkg.Repo
+			if pkg.Source != "github" {
+				structured.Source = pkg.Source
+				structured.Host = pkg.Host
+			}
+			name = pkg.Repo
+		}
+		name = uniquePackageName(name, usedNames)
+		usedNames[name] = true
+		for _, group := range pkg.StewfileGroups {
+			groupPackages[group] = append(groupPackages[group], name)
+		}
+
+		// Each table is written separately so that the packages keep their order
+		if index > 0 {
+			buffer.WriteString("\n")
+		}
+		fmt.Fprintf(&buffer, "[packages.%v]\n", tomlKey(name))
+		if err := toml.NewEncoder(&buffer).Encode(structured); err != nil {
+			return "", err
+		}
+
+		platforms := make([]string, 0, len(pkg.Overrides))
+		for platform := range pkg.Overrides {
+			platforms = append(platforms, platform)
+		}
+		sort.Strings(platforms)
+		for _, platform := range platforms {
+			override := pkg.Overrides[platform]
+			fmt.Fprintf(&buffer, "\n[packages.%v.platforms.%q]\n", tomlKey(name), platform)
+			err := toml.NewEncoder(&buffer).Encode(structuredPlatform{Asset: override.Asset, AssetPattern: override.AssetPattern})
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	for _, group := range StewfileGroupNames(packages) {
+		fmt.Fprintf(&buffer, "\n[group.%v]\n", group)
+		if err := toml.NewEncoder(&buffer).Encode(structuredGroup{Packages: groupPackages[group]}); err != nil {
+			return "", err
+		}
+	}
+	return buffer.String(), nil
+}
@@ -0,0 +1,216 @@
+package stew
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIsStructuredStewfile(t *testing.T) {
+	tests := []struct {
+		name         string
+		stewfilePath string
+		want         bool
+	}{
+		{
+			name:         "test1",
+			stewfilePath: "Stewfile",
+			want:         false,
+		},
+		{
+			name:         "test2",
+			stewfilePath: filepath.Join("dotfiles", "Stewfile.toml"),
+			want:         true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsStructuredStewfile(tt.stewfilePath); got != tt.want {
+				t.Errorf("IsStructuredStewfile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateStewfile_structured(t *testing.T) {
+	testStewfilePath := filepath.Join(t.TempDir(), "Stewfile.toml")
+	contents := `[packages.rg]
+repo = "BurntSushi/ripgrep"
+constraint = ">=13, <15"
+asset_pattern = "x86_64-unknown-linux-musl"
+binary = "rg"
+extra_files = ["complete/_rg"]
+
+[packages.rg.platforms."darwin/arm64"]
+asset_pattern = "aarch64-apple-darwin"
+
+[packages.fzf]
+repo = "junegunn/fzf"
+tag = "0.29.0"
+channel = "prerelease"
+
+[packages.kubectl]
+url = "https://dl.k8s.io/release/v1.29.0/bin/linux/amd64/kubectl"
+os = ["linux"]
+arch = ["amd64"]
+
+[packages.bad]
+repo = "junegunn"
+
+[packages.fzf-again]
+repo = "junegunn/fzf"
+`
+	err := os.WriteFile(testStewfilePath, []byte(contents), 0644)
+	if err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	packages, errs, err := ValidateStewfile(testStewfilePath)
+	if err != nil {
+		t.Fatalf("ValidateStewfile() error = %v", err)
+	}
+	want := []PackageData{
+		{
+			Source:       "github",
+			Owner:        "BurntSushi",
+			Repo:         "ripgrep",
+			Binary:       "rg",
+			AssetPattern: "x86_64-unknown-linux-musl",
+			Constraint:   ">=13, <15",
+			Channel:      "stable",
+			ExtraFiles:   []string{"complete/_rg"},
+			Overrides:    map[string]PlatformOverride{"darwin/arm64": {AssetPattern: "aarch64-apple-darwin"}},
+		},
+		{
+			Source:  "github",
+			Owner:   "junegunn",
+			Repo:    "fzf",
+			Tag:     "0.29.0",
+			Channel: "prerelease",
+		},
+		{
+			Source:   "other",
+			URL:      "https://dl.k8s.io/release/v1.29.0/bin/linux/amd64/kubectl",
+			OnlyOS:   []string{"linux"},
+			OnlyArch: []string{"amd64"},
+		},
+	}
+	if !reflect.DeepEqual(packages, want) {
+		t.Errorf("ValidateStewfile() got = %+v, want %+v", packages, want)
+	}
+	if len(errs) != 2 {
+		t.Errorf("ValidateStewfile() got %v errors, want 2: %v", len(errs), errs)
+	}
+}
+
+func TestValidateStewfile_structuredSyntaxError(t *testing.T) {
+	testStewfilePath := filepath.Join(t.TempDir(), "Stewfile.toml")
+	err := os.WriteFile(testStewfilePath, []byte("[packages.fzf]\nrepo = junegunn/fzf\n"), 0644)
+	if err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	_, errs, err := ValidateStewfile(testStewfilePath)
+	if err != nil {
+		t.Fatalf("ValidateStewfile() error = %v", err)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("ValidateStewfile() got %v errors, want 1", len(errs))
+	}
+	parseErr, ok := errs[0].(StewfileParseError)
+	if !ok || parseErr.Line != 2 {
+		t.Errorf("ValidateStewfile() error = %v, want a StewfileParseError on line 2", errs[0])
+	}
+}
+
+func TestConvertStewfile(t *testing.T) {
+	packages := []PackageData{
+		{
+			Source: "github",
+			Owner:  "junegunn",
+			Repo:   "fzf",
+			Tag:    "0.29.0",
+			Asset:  "fzf-0.29.0-linux_amd64.tar.gz",
+		},
+		{
+			Source: "gitea",
+			Owner:  "marwan",
+			Repo:   "fzf",
+			Host:   "gitea.example.com",
+		},
+		{
+			Source: "other",
+			Asset:  "kubectl",
+			URL:    "https://dl.k8s.io/release/v1.29.0/bin/linux/amd64/kubectl",
+		},
+	}
+	want := `[packages.fzf]
+repo = "junegunn/fzf"
+tag = "0.29.0"
+asset = "fzf-0.29.0-linux_amd64.tar.gz"
+
+[packages.fzf-2]
+repo = "marwan/fzf"
+source = "gitea"
+host = "gitea.example.com"
+
+[packages.kubectl]
+url = "https://dl.k8s.io/release/v1.29.0/bin/linux/amd64/kubectl"
+`
+	got, err := ConvertStewfile(packages)
+	if err != nil {
+		t.Fatalf("ConvertStewfile() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("ConvertStewfile() got = %v, want %v", got, want)
+	}
+}
+
+func TestConvertStewfile_names(t *testing.T) {
+	packages := []PackageData{
+		{Source: "github", Owner: "junegunn", Repo: "fzf-2"},
+		{Source: "github", Owner: "junegunn", Repo: "fzf"},
+		{Source: "gitea", Owner: "marwan", Repo: "fzf", Host: "gitea.example.com"},
+		{Source: "other", URL: "https://example.com/downloads/..."},
+		{Source: "other", URL: "https://example.com/downloads/-"},
+	}
+	got, err := ConvertStewfile(packages)
+	if err != nil {
+		t.Fatalf("ConvertStewfile() error = %v", err)
+	}
+	for _, table := range []string{"[packages.fzf-2]", "[packages.fzf]", "[packages.fzf-3]", "[packages.package]", "[packages.package-2]"} {
+		if strings.Count(got, table+"\n") != 1 {
+			t.Errorf("ConvertStewfile() should write the table %v once, got %v", table, got)
+		}
+	}
+}
+
+func TestValidateStewfile_structuredGroups(t *testing.T) {
+	testStewfilePath := filepath.Join(t.TempDir(), "Stewfile.toml")
+	contents := `[packages.fzf]
+repo = "junegunn/fzf"
+
+[packages.kubectl]
+repo = "kubernetes/kubectl"
+
+[group.k8s]
+packages = ["kubectl", "helm"]
+`
+	err := os.WriteFile(testStewfilePath, []byte(contents), 0644)
+	if err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	packages, errs, err := ValidateStewfile(testStewfilePath)
+	if err != nil {
+		t.Fatalf("ValidateStewfile() error = %v", err)
+	}
+	if len(packages) != 2 || packages[0].StewfileGroups != nil || !reflect.DeepEqual(packages[1].StewfileGroups, []string{"k8s"}) {
+		t.Errorf("ValidateStewfile() got = %+v, want kubectl in the k8s group", packages)
+	}
+	if len(errs) != 1 {
+		t.Errorf("ValidateStewfile() got %v errors, want 1: %v", len(errs), errs)
+	}
+}
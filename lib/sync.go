@@ -0,0 +1,107 @@
+package stew
+
+import (
+	"fmt"
+
+	"github.com/marwanhawari/stew/constants"
+)
+
+// SyncAction is the change that stew sync will make for a single package
+type SyncAction string
+
+const (
+	// SyncInstall installs a package from the Stewfile that is not installed yet
+	SyncInstall SyncAction = "install"
+	// SyncUpgrade replaces an installed package with the newer tag pinned in the Stewfile
+	SyncUpgrade SyncAction = "upgrade"
+	// SyncDowngrade replaces an installed package with the older tag pinned in the Stewfile
+	SyncDowngrade SyncAction = "downgrade"
+	// SyncReinstall replaces an installed package with the asset pinned in the Stewfile
+	SyncReinstall SyncAction = "reinstall"
+	// SyncRemove uninstalls a package that is no longer in the Stewfile
+	SyncRemove SyncAction = "remove"
+)
+
+// SyncStep contains a single change of a sync plan. Installed is only set for packages that are already installed.
+type SyncStep struct {
+	Action    SyncAction
+	Package   PackageData
+	Installed PackageData
+}
+
+// NewSyncPlan computes the steps needed to make the installed packages match the selected entries of the Stewfile.
+// Entries without a pinned tag are left alone if they are installed. Extra packages are only removed if prune is set,
+// and only if they are not in stewfilePackages, which holds every entry of the Stewfile. Entries of groups that were
+// not selected or for other platforms are left alone that way.
+func NewSyncPlan(selectedPackages, stewfilePackages []PackageData, lockFile LockFile, prune bool) []SyncStep {
+	plan := []SyncStep{}
+	for _, pkg := range selectedPackages {
+		index, found := FindPackageInLockFile(lockFile, pkg)
+		if !found {
+			plan = append(plan, SyncStep{Action: SyncInstall, Package: pkg})
+			continue
+		}
+		installedPkg := lockFile.Packages[index]
+		step := SyncStep{Package: pkg, Installed: installedPkg}
+		switch {
+		case pkg.Tag != "" && pkg.Tag != "latest" && pkg.Tag != installedPkg.Tag:
+			step.Action = SyncUpgrade
+			if CompareTags(pkg.Tag, installedPkg.Tag) < 0 {
+				step.Action = SyncDowngrade
+			}
+		case pkg.Asset != "" && pkg.Asset != installedPkg.Asset:
+			step.Action = SyncReinstall
+		default:
+			continue
+		}
+		plan = append(plan, step)
+	}
+
+	if prune {
+		wanted := make(map[int]bool, len(lockFile.Packages))
+		for _, pkg := range stewfilePackages {
+			if index, found := FindPackageInLockFile(lockFile, pkg); found {
+				wanted[index] = true
+			}
+		}
+		for index, installedPkg := range lockFile.Packages {
+			if !wanted[index] {
+				plan = append(plan, SyncStep{Action: SyncRemove, Installed: installedPkg})
+			}
+		}
+	}
+
+	return plan
+}
+
+// FormatSyncStep formats a step of a sync plan for the terminal
+func FormatSyncStep(step SyncStep) string {
+	switch step.Action {
+	case SyncInstall:
+		name := GetPackageOwner(step.Package) + "/" + step.Package.Repo
+		if step.Package.Source == "other" {
+			name = step.Package.URL
+		} else if step.Package.Tag != "" {
+			name += "@" + step.Package.Tag
+		}
+		return fmt.Sprintf("%v install %v", constants.GreenColor("+"), constants.GreenColor(name))
+	case SyncUpgrade, SyncDowngrade:
+		return fmt.Sprintf(
+			"%v %v %v %v → %v",
+			constants.YellowColor("~"),
+			step.Action,
+			constants.YellowColor(step.Installed.Binary),
+			step.Installed.Tag,
+			constants.YellowColor(step.Package.Tag),
+		)
+	case SyncReinstall:
+		return fmt.Sprintf(
+			"%v reinstall %v with %v",
+			constants.YellowColor("~"),
+			constants.YellowColor(step.Installed.Binary),
+			constants.YellowColor(step.Package.Asset),
+		)
+	default:
+		return fmt.Sprintf("%v remove %v", constants.RedColor("-"), constants.RedColor(step.Installed.Binary))
+	}
+}
@@ -0,0 +1,113 @@
+package stew
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewSyncPlan(t *testing.T) {
+	type args struct {
+		selectedPackages []PackageData
+		stewfilePackages []PackageData
+		prune            bool
+	}
+	tests := []struct {
+		name string
+		args args
+		want []SyncStep
+	}{
+		{
+			name: "test1",
+			args: args{
+				selectedPackages: []PackageData{
+					{Source: "github", Owner: "junegunn", Repo: "fzf", Tag: "0.29.0"},
+					{Source: "other", URL: testLockfile.Packages[1].URL},
+					{Source: "github", Owner: "marwanhawari", Repo: "ppath"},
+				},
+				prune: true,
+			},
+			want: []SyncStep{},
+		},
+		{
+			name: "test2",
+			args: args{
+				selectedPackages: []PackageData{
+					{Source: "github", Owner: "junegunn", Repo: "fzf", Tag: "0.30.0"},
+					{Source: "github", Owner: "marwanhawari", Repo: "ppath", Tag: "v0.0.2"},
+					{Source: "github", Owner: "sharkdp", Repo: "fd"},
+				},
+				prune: false,
+			},
+			want: []SyncStep{
+				{
+					Action:    SyncUpgrade,
+					Package:   PackageData{Source: "github", Owner: "junegunn", Repo: "fzf", Tag: "0.30.0"},
+					Installed: testLockfile.Packages[0],
+				},
+				{
+					Action:    SyncDowngrade,
+					Package:   PackageData{Source: "github", Owner: "marwanhawari", Repo: "ppath", Tag: "v0.0.2"},
+					Installed: testLockfile.Packages[2],
+				},
+				{
+					Action:  SyncInstall,
+					Package: PackageData{Source: "github", Owner: "sharkdp", Repo: "fd"},
+				},
+			},
+		},
+		{
+			name: "test3",
+			args: args{
+				selectedPackages: []PackageData{
+					{Source: "github", Owner: "marwanhawari", Repo: "ppath", Tag: "v0.0.3", Asset: "ppath-v0.0.3-darwin-amd64.tar.gz"},
+				},
+				prune: true,
+			},
+			want: []SyncStep{
+				{
+					Action:    SyncReinstall,
+					Package:   PackageData{Source: "github", Owner: "marwanhawari", Repo: "ppath", Tag: "v0.0.3", Asset: "ppath-v0.0.3-darwin-amd64.tar.gz"},
+					Installed: testLockfile.Packages[2],
+				},
+				{
+					Action:    SyncRemove,
+					Installed: testLockfile.Packages[0],
+				},
+				{
+					Action:    SyncRemove,
+					Installed: testLockfile.Packages[1],
+				},
+			},
+		},
+		{
+			name: "test4",
+			args: args{
+				selectedPackages: []PackageData{
+					{Source: "github", Owner: "junegunn", Repo: "fzf", Tag: "0.29.0"},
+				},
+				stewfilePackages: []PackageData{
+					{Source: "github", Owner: "junegunn", Repo: "fzf", Tag: "0.29.0", StewfileGroups: []string{"dev"}},
+					{Source: "github", Owner: "marwanhawari", Repo: "ppath", StewfileGroups: []string{"k8s"}},
+				},
+				prune: true,
+			},
+			want: []SyncStep{
+				{
+					Action:    SyncRemove,
+					Installed: testLockfile.Packages[1],
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stewfilePackages := tt.args.stewfilePackages
+			if stewfilePackages == nil {
+				stewfilePackages = tt.args.selectedPackages
+			}
+			if got := NewSyncPlan(tt.args.selectedPackages, stewfilePackages, testLockfile, tt.args.prune); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewSyncPlan() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
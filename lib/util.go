@@ -1,6 +1,8 @@
 package stew
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
@@ -107,6 +109,9 @@ func DownloadFile(downloadPath string, urlInput string, hostType string) error {
 		return NonZeroStatusCodeDownloadError{StatusCode: resp.StatusCode}
 	}
 
+	if err := os.MkdirAll(filepath.Dir(downloadPath), 0755); err != nil {
+		return err
+	}
 	outputFile, err := os.Create(downloadPath)
 	if err != nil {
 		return err
@@ -130,6 +135,21 @@ func DownloadFile(downloadPath string, urlInput string, hostType string) error {
 	return nil
 }
 
+// SHA256File returns the hex encoded SHA256 digest of a file
+func SHA256File(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
 func copyFile(srcFile, destFile string) error {
 	srcContents, err := os.Open(srcFile)
 	if err != nil {
@@ -151,6 +171,15 @@ func copyFile(srcFile, destFile string) error {
 	return os.Chmod(destFile, 0755)
 }
 
+// writeFileAtomic writes a file next to its destination and renames it into place, so that readers never see a partial file
+func writeFileAtomic(filePath string, contents []byte, perm os.FileMode) error {
+	tmpFilePath := filePath + ".tmp"
+	if err := os.WriteFile(tmpFilePath, contents, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpFilePath, filePath)
+}
+
 func walkDir(rootDir string) ([]string, error) {
 	allFilePaths := []string{}
 	err := filepath.Walk(rootDir, func(filePath string, fileInfo os.FileInfo, err error) error {
@@ -333,6 +362,48 @@ func parseURLInput(cliInput string) (CLIInput, error) {
 	return CLIInput{IsGithubInput: false, Asset: filepath.Base(cliInput), DownloadURL: cliInput}, nil
 }
 
+const defaultGitlabHost = "gitlab.com"
+
+// PackageReference contains the input and host that stew install needs to install a package again
+type PackageReference struct {
+	Input    string
+	Host     string
+	HostType string
+}
+
+// GetPackageReference turns a Stewfile or lockfile entry back into an install input that uses the source, host and
+// groups of the entry itself. GitLab entries without a host are installed from gitlab.com.
+func GetPackageReference(pkg PackageData) PackageReference {
+	if pkg.Source == "other" {
+		return PackageReference{Input: pkg.URL}
+	}
+
+	owner := GetPackageOwner(pkg)
+	if pkg.Source == "gitlab" {
+		owner = strings.Join(GetPackageGroups(pkg), "/")
+	}
+	input := owner + "/" + pkg.Repo
+	if pkg.Tag != "" || pkg.Asset != "" {
+		input += "@" + pkg.Tag
+	}
+	if pkg.Asset != "" {
+		input += "#" + pkg.Asset
+	}
+
+	switch pkg.Source {
+	case "gitlab":
+		host := pkg.Host
+		if host == "" {
+			host = defaultGitlabHost
+		}
+		return PackageReference{Input: input, Host: host, HostType: "gitlab"}
+	case "gitea":
+		return PackageReference{Input: input, Host: pkg.Host, HostType: "gitea"}
+	default:
+		return PackageReference{Input: input, HostType: "github"}
+	}
+}
+
 // Contains checks if a string slice contains a given target
 func Contains[T comparable](slice []T, target T) (int, bool) {
 	for index, element := range slice {
@@ -352,7 +423,27 @@ func FindBinaryInLockFile(lockFile LockFile, binaryName string) (int, bool) {
 	return -1, false
 }
 
-func extractBinary(downloadedFilePath, tmpExtractionPath string) error {
+// FindPackageInLockFile finds the lockfile entry that was installed from the same source as pkg
+func FindPackageInLockFile(lockFile LockFile, pkg PackageData) (int, bool) {
+	for index, lockedPkg := range lockFile.Packages {
+		if lockedPkg.Source != pkg.Source {
+			continue
+		}
+		if pkg.Source == "other" {
+			if lockedPkg.URL == pkg.URL {
+				return index, true
+			}
+			continue
+		}
+		sameHost := pkg.Source == "github" || lockedPkg.Host == pkg.Host
+		if GetPackageOwner(lockedPkg) == GetPackageOwner(pkg) && lockedPkg.Repo == pkg.Repo && sameHost {
+			return index, true
+		}
+	}
+	return -1, false
+}
+
+func extractBinary(downloadedFilePath, tmpExtractionPath, binaryName string) error {
 	isArchive := isArchiveFile(downloadedFilePath)
 	if isArchive {
 		err := archiver.Unarchive(downloadedFilePath, tmpExtractionPath)
@@ -361,12 +452,14 @@ func extractBinary(downloadedFilePath, tmpExtractionPath string) error {
 		}
 		return nil
 	}
-	originalBinaryName := filepath.Base(downloadedFilePath)
-	renamedBinaryName, err := PromptRenameBinary(originalBinaryName)
-	if err != nil {
-		return err
+	if binaryName == "" {
+		var err error
+		binaryName, err = PromptRenameBinary(filepath.Base(downloadedFilePath))
+		if err != nil {
+			return err
+		}
 	}
-	return copyFile(downloadedFilePath, filepath.Join(tmpExtractionPath, renamedBinaryName))
+	return copyFile(downloadedFilePath, filepath.Join(tmpExtractionPath, binaryName))
 }
 
 // InstallBinary will extract the binary and copy it to the ~/.stew/bin path
@@ -377,26 +470,152 @@ func InstallBinary(
 	lockFile *LockFile,
 	overwriteFromUpgrade bool,
 ) (string, error) {
-	tmpExtractionPath, stewPkgPath, binaryInstallPath := systemInfo.StewTmpPath, systemInfo.StewPkgPath, systemInfo.StewBinPath
-	if err := extractBinary(downloadedFilePath, tmpExtractionPath); err != nil {
-		return "", err
+	binaryName, _, err := InstallPackageBinary(downloadedFilePath, repo, PackageData{}, systemInfo, lockFile, overwriteFromUpgrade)
+	return binaryName, err
+}
+
+// InstallPackageBinary will extract the binary and copy it to the ~/.stew/bin path using the options of a Stewfile entry.
+// The binary is installed under the binary name of the entry if it is set, and the extra files of the entry are
+// copied next to it. Each version is kept in ~/.stew/pkg/<binary>/<tag> and linked into the ~/.stew/bin path,
+// so the tag of the entry should be the tag that is being installed. It returns the binary name and the names
+// of the installed extra files.
+func InstallPackageBinary(
+	downloadedFilePath string,
+	repo string,
+	spec PackageData,
+	systemInfo SystemInfo,
+	lockFile *LockFile,
+	overwriteFromUpgrade bool,
+) (string, []string, error) {
+	tmpExtractionPath := systemInfo.StewTmpPath
+	if err := extractBinary(downloadedFilePath, tmpExtractionPath, spec.Binary); err != nil {
+		return "", nil, err
 	}
 
 	allFilePaths, err := walkDir(tmpExtractionPath)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	binaryFileInTmpExtractionPath, binaryName, err := getBinary(allFilePaths, repo)
+	binaryHint := repo
+	if spec.Binary != "" {
+		binaryHint = spec.Binary
+	}
+	binaryFileInTmpExtractionPath, binaryName, err := getBinary(allFilePaths, binaryHint)
+	if err != nil {
+		return "", nil, err
+	}
+	if spec.Binary != "" {
+		binaryName = spec.Binary
+	}
+
+	extraFilesInTmpExtractionPath, err := findExtraFiles(allFilePaths, tmpExtractionPath, spec.ExtraFiles)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err = handleExistingBinary(lockFile, binaryName, downloadedFilePath, systemInfo, overwriteFromUpgrade); err != nil {
+		return "", nil, err
+	}
+
+	version := spec.Tag
+	if version == "" {
+		version = filepath.Base(downloadedFilePath)
+	}
+	extraFiles, err := installVersion(systemInfo, binaryName, version, binaryFileInTmpExtractionPath, extraFilesInTmpExtractionPath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	err = os.RemoveAll(tmpExtractionPath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return binaryName, extraFiles, nil
+}
+
+// findExtraFiles finds the files matching the extra file patterns of a package.
+// A pattern is matched against both the path relative to the extraction path and the file name.
+func findExtraFiles(filePaths []string, tmpExtractionPath string, patterns []string) ([]string, error) {
+	extraFiles := []string{}
+	for _, pattern := range patterns {
+		patternFound := false
+		for _, fullPath := range filePaths {
+			relativePath, err := filepath.Rel(tmpExtractionPath, fullPath)
+			if err != nil {
+				return nil, err
+			}
+			relativeMatch, err := filepath.Match(pattern, relativePath)
+			if err != nil {
+				return nil, err
+			}
+			baseMatch, _ := filepath.Match(pattern, filepath.Base(fullPath))
+			if relativeMatch || baseMatch {
+				extraFiles = append(extraFiles, fullPath)
+				patternFound = true
+			}
+		}
+		if !patternFound {
+			return nil, ExtraFileNotFoundError{Pattern: pattern}
+		}
+	}
+	return extraFiles, nil
+}
+
+// DeleteExtraFiles deletes the extra files that were installed next to a binary
+func DeleteExtraFiles(stewBinPath string, extraFiles []string) error {
+	for _, extraFile := range extraFiles {
+		err := os.RemoveAll(filepath.Join(stewBinPath, extraFile))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InstallLockedBinary will extract and install the binary of a lockfile entry without prompting.
+// Any binary that is already installed under the same name is overwritten.
+func InstallLockedBinary(
+	downloadedFilePath string,
+	pkg PackageData,
+	systemInfo SystemInfo,
+	lockFile *LockFile,
+) (string, error) {
+	tmpExtractionPath := systemInfo.StewTmpPath
+	if isArchiveFile(downloadedFilePath) {
+		if err := archiver.Unarchive(downloadedFilePath, tmpExtractionPath); err != nil {
+			return "", err
+		}
+	} else {
+		binaryName := pkg.Binary
+		if binaryName == "" {
+			binaryName = filepath.Base(downloadedFilePath)
+		}
+		if err := copyFile(downloadedFilePath, filepath.Join(tmpExtractionPath, binaryName)); err != nil {
+			return "", err
+		}
+	}
+
+	allFilePaths, err := walkDir(tmpExtractionPath)
 	if err != nil {
 		return "", err
 	}
 
-	if err = handleExistingBinary(lockFile, binaryName, downloadedFilePath, stewPkgPath, overwriteFromUpgrade); err != nil {
+	binaryFileInTmpExtractionPath, binaryName, err := findLockedBinary(allFilePaths, pkg)
+	if err != nil {
 		return "", err
 	}
 
-	err = copyFile(binaryFileInTmpExtractionPath, filepath.Join(binaryInstallPath, binaryName))
+	indexInLockFile, binaryFoundInLockFile := FindBinaryInLockFile(*lockFile, binaryName)
+	if binaryFoundInLockFile {
+		err = overwriteBinary(lockFile, indexInLockFile, downloadedFilePath, systemInfo, false)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	_, err = installVersion(systemInfo, binaryName, InstalledVersionName(pkg), binaryFileInTmpExtractionPath, nil)
 	if err != nil {
 		return "", err
 	}
@@ -409,9 +628,53 @@ func InstallBinary(
 	return binaryName, nil
 }
 
+// findLockedBinary finds the binary of a lockfile entry in the extracted files. Entries that were locked
+// without being installed have no binary name, so the same detection as getBinary is used, minus the prompts.
+func findLockedBinary(filePaths []string, pkg PackageData) (string, string, error) {
+	executableFiles := []string{}
+	for _, fullPath := range filePaths {
+		fileNameBase := filepath.Base(fullPath)
+		if pkg.Binary != "" && fileNameBase == pkg.Binary {
+			return fullPath, pkg.Binary, nil
+		}
+		fileIsExecutable, err := isExecutableFile(fullPath)
+		if err != nil {
+			return "", "", err
+		}
+		if pkg.Binary == "" && (fileNameBase == pkg.Repo && fileIsExecutable || filepath.Ext(fullPath) == ".exe") {
+			return fullPath, fileNameBase, nil
+		}
+		if fileIsExecutable {
+			executableFiles = append(executableFiles, fullPath)
+		}
+	}
+
+	if len(executableFiles) != 1 {
+		return "", "", FrozenLockFileError{Package: pkg.Asset, Reason: "could not find the binary in the asset"}
+	}
+	binaryName := pkg.Binary
+	if binaryName == "" {
+		binaryName = filepath.Base(executableFiles[0])
+	}
+	return executableFiles[0], binaryName, nil
+}
+
+// VerifySHA256 makes sure a downloaded file matches the SHA256 digest recorded in the lockfile
+func VerifySHA256(filePath, expectedSHA256 string) error {
+	actualSHA256, err := SHA256File(filePath)
+	if err != nil {
+		return err
+	}
+	if actualSHA256 != expectedSHA256 {
+		return ChecksumMismatchError{Asset: filepath.Base(filePath), Expected: expectedSHA256, Actual: actualSHA256}
+	}
+	return nil
+}
+
 func handleExistingBinary(
 	lockFile *LockFile,
-	binaryName, newlyDownloadedAssetPath, stewPkgPath string,
+	binaryName, newlyDownloadedAssetPath string,
+	systemInfo SystemInfo,
 	overwriteFromUpgrade bool,
 ) error {
 	indexInLockFile, binaryFoundInLockFile := FindBinaryInLockFile(*lockFile, binaryName)
@@ -440,18 +703,24 @@ func handleExistingBinary(
 			return AbortBinaryOverwriteError{Binary: binaryName}
 		}
 	}
-	return overwriteBinary(lockFile, indexInLockFile, newlyDownloadedAssetPath, stewPkgPath, overwriteFromUpgrade)
+	return overwriteBinary(lockFile, indexInLockFile, newlyDownloadedAssetPath, systemInfo, overwriteFromUpgrade)
 }
 
 func overwriteBinary(
 	lockFile *LockFile,
 	indexInLockFile int,
-	newlyDownloadedAssetPath, stewPkgPath string,
+	newlyDownloadedAssetPath string,
+	systemInfo SystemInfo,
 	overwriteFromUpgrade bool,
 ) error {
 	pkg := lockFile.Packages[indexInLockFile]
-	previousAssetPath := filepath.Join(stewPkgPath, pkg.Asset)
-	if previousAssetPath != newlyDownloadedAssetPath {
+	previousAssetPath := filepath.Join(systemInfo.StewAssetPath, pkg.Asset)
+	// The previous version stays installed next to the new one if it was installed in its own version directory
+	previousVersionInstalled, err := PathExists(InstalledVersionPath(systemInfo.StewPkgPath, pkg.Binary, InstalledVersionName(pkg)))
+	if err != nil {
+		return err
+	}
+	if previousAssetPath != newlyDownloadedAssetPath && !previousVersionInstalled {
 		if err := os.RemoveAll(previousAssetPath); err != nil {
 			return err
 		}
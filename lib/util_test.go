@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -176,7 +177,7 @@ func TestDownloadFile(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tempDir := t.TempDir()
 			testDownloadPath := filepath.Join(tempDir, filepath.Base(tt.args.url))
-			if err := DownloadFile(testDownloadPath, tt.args.url); (err != nil) != tt.wantErr {
+			if err := DownloadFile(testDownloadPath, tt.args.url, "github"); (err != nil) != tt.wantErr {
 				t.Errorf("DownloadFile() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
@@ -187,6 +188,22 @@ func TestDownloadFile(t *testing.T) {
 	}
 }
 
+func TestSHA256File(t *testing.T) {
+	testFilePath := filepath.Join(t.TempDir(), "testFile")
+	err := os.WriteFile(testFilePath, []byte("stew\n"), 0644)
+	if err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	got, err := SHA256File(testFilePath)
+	if err != nil {
+		t.Fatalf("SHA256File() error = %v", err)
+	}
+	want := "f14515efd10b46142f50cb68fe727fa7c65dfa2e7b2d5f2212f4f417c2e9828d"
+	if got != want {
+		t.Errorf("SHA256File() = %v, want %v", got, want)
+	}
+}
+
 func Test_copyFile(t *testing.T) {
 	type args struct {
 		srcFile  string
@@ -359,6 +376,78 @@ func Test_getBinary(t *testing.T) {
 	}
 }
 
+func Test_findLockedBinary(t *testing.T) {
+	tests := []struct {
+		name           string
+		pkg            PackageData
+		wantBinaryName string
+		wantErr        bool
+	}{
+		{
+			name:           "test1",
+			pkg:            PackageData{Repo: "someRepo", Binary: "testBinary"},
+			wantBinaryName: "testBinary",
+			wantErr:        false,
+		},
+		{
+			name:           "test2",
+			pkg:            PackageData{Repo: "someRepo", Binary: "renamedBinary"},
+			wantBinaryName: "renamedBinary",
+			wantErr:        false,
+		},
+		{
+			name:           "test3",
+			pkg:            PackageData{Repo: "testBinary"},
+			wantBinaryName: "testBinary",
+			wantErr:        false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+
+			testBinaryFilePath := filepath.Join(tempDir, "testBinary")
+			err := os.WriteFile(testBinaryFilePath, []byte("An executable file"), 0755)
+			if err != nil {
+				t.Errorf("Could not write file %v", err)
+				return
+			}
+			testNonBinaryFilePath := filepath.Join(tempDir, "testNonBinary")
+			err = os.WriteFile(testNonBinaryFilePath, []byte("Not an executable file"), 0644)
+			if err != nil {
+				t.Errorf("Could not write file %v", err)
+				return
+			}
+
+			got, got1, err := findLockedBinary([]string{testNonBinaryFilePath, testBinaryFilePath}, tt.pkg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("findLockedBinary() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != testBinaryFilePath {
+				t.Errorf("findLockedBinary() got = %v, want %v", got, testBinaryFilePath)
+			}
+			if got1 != tt.wantBinaryName {
+				t.Errorf("findLockedBinary() got1 = %v, want %v", got1, tt.wantBinaryName)
+			}
+		})
+	}
+}
+
+func TestVerifySHA256(t *testing.T) {
+	testFilePath := filepath.Join(t.TempDir(), "testFile")
+	err := os.WriteFile(testFilePath, []byte("stew\n"), 0644)
+	if err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := VerifySHA256(testFilePath, "f14515efd10b46142f50cb68fe727fa7c65dfa2e7b2d5f2212f4f417c2e9828d"); err != nil {
+		t.Errorf("VerifySHA256() error = %v", err)
+	}
+	if err := VerifySHA256(testFilePath, "0000"); err == nil {
+		t.Errorf("VerifySHA256() expected a checksum mismatch")
+	}
+}
+
 func Test_getBinaryError(t *testing.T) {
 	type args struct {
 		repo string
@@ -495,7 +584,7 @@ func TestParseCLIInput(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := ParseCLIInput(tt.args.cliInput)
+			got, err := ParseCLIInput(tt.args.cliInput, "github")
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ParseCLIInput() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -637,6 +726,45 @@ func TestContains(t *testing.T) {
 	}
 }
 
+func TestFindPackageInLockFile(t *testing.T) {
+	tests := []struct {
+		name  string
+		pkg   PackageData
+		want  int
+		want1 bool
+	}{
+		{
+			name:  "test1",
+			pkg:   PackageData{Source: "github", Owner: "marwanhawari", Repo: "ppath", Tag: "v0.0.2"},
+			want:  2,
+			want1: true,
+		},
+		{
+			name:  "test2",
+			pkg:   PackageData{Source: "other", URL: testLockfile.Packages[1].URL},
+			want:  1,
+			want1: true,
+		},
+		{
+			name:  "test3",
+			pkg:   PackageData{Source: "gitea", Owner: "marwanhawari", Repo: "ppath", Host: "git.example.com"},
+			want:  -1,
+			want1: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, got1 := FindPackageInLockFile(testLockfile, tt.pkg)
+			if got != tt.want {
+				t.Errorf("FindPackageInLockFile() got = %v, want %v", got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("FindPackageInLockFile() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
+
 func Test_extractBinary(t *testing.T) {
 	type args struct {
 		downloadedFilePath string
@@ -660,12 +788,12 @@ func Test_extractBinary(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := DownloadFile(tt.args.downloadedFilePath, tt.url)
+			err := DownloadFile(tt.args.downloadedFilePath, tt.url, "github")
 			if err != nil {
 				t.Errorf("Could not download file %v", err)
 			}
 
-			if err := extractBinary(tt.args.downloadedFilePath, tt.args.tmpExtractionPath); (err != nil) != tt.wantErr {
+			if err := extractBinary(tt.args.downloadedFilePath, tt.args.tmpExtractionPath, ""); (err != nil) != tt.wantErr {
 				t.Errorf("extractBinary() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -729,6 +857,7 @@ func TestInstallBinary(t *testing.T) {
 			err = DownloadFile(
 				downloadedFilePath,
 				"https://github.com/marwanhawari/ppath/releases/download/v0.0.3/ppath-v0.0.3-darwin-arm64.tar.gz",
+				"github",
 			)
 			if err != nil {
 				t.Errorf("Could not download file to %v", downloadedFilePath)
@@ -803,6 +932,7 @@ func TestInstallBinary_Fail(t *testing.T) {
 			err = DownloadFile(
 				downloadedFilePath,
 				"https://github.com/marwanhawari/ppath/releases/download/v0.0.3/ppath-v0.0.3-darwin-arm64.tar.gz",
+				"github",
 			)
 			if err != nil {
 				t.Errorf("Could not download file to %v", downloadedFilePath)
@@ -819,3 +949,119 @@ func TestInstallBinary_Fail(t *testing.T) {
 		})
 	}
 }
+
+func Test_findExtraFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"rg", filepath.Join("complete", "_rg"), filepath.Join("doc", "rg.1")} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(tempDir, name)), 0755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("stew\n"), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	filePaths, err := walkDir(tempDir)
+	if err != nil {
+		t.Fatalf("walkDir() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name:     "test1",
+			patterns: []string{"complete/_rg", "*.1"},
+			want:     []string{filepath.Join(tempDir, "complete", "_rg"), filepath.Join(tempDir, "doc", "rg.1")},
+		},
+		{
+			name:     "test2",
+			patterns: []string{"*.bash"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findExtraFiles(filePaths, tempDir, tt.patterns)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("findExtraFiles() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findExtraFiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetPackageReference(t *testing.T) {
+	tests := []struct {
+		name string
+		pkg  PackageData
+		want PackageReference
+	}{
+		{
+			name: "test1",
+			pkg:  PackageData{Source: "github", Owner: "junegunn", Repo: "fzf"},
+			want: PackageReference{Input: "junegunn/fzf", HostType: "github"},
+		},
+		{
+			name: "test2",
+			pkg:  PackageData{Source: "github", Owner: "junegunn", Repo: "fzf", Tag: "0.29.0", Asset: "fzf-0.29.0-linux_amd64.tar.gz"},
+			want: PackageReference{Input: "junegunn/fzf@0.29.0#fzf-0.29.0-linux_amd64.tar.gz", HostType: "github"},
+		},
+		{
+			name: "test3",
+			pkg:  PackageData{Source: "gitlab", Groups: []string{"group", "subgroup"}, Repo: "project", Host: "gitlab.example.com"},
+			want: PackageReference{Input: "group/subgroup/project", Host: "gitlab.example.com", HostType: "gitlab"},
+		},
+		{
+			name: "test4",
+			pkg:  PackageData{Source: "gitlab", Owner: "gitlab-org", Repo: "cli", Tag: "v1.36.0"},
+			want: PackageReference{Input: "gitlab-org/cli@v1.36.0", Host: "gitlab.com", HostType: "gitlab"},
+		},
+		{
+			name: "test5",
+			pkg:  PackageData{Source: "gitea", Owner: "abs3nt", Repo: "gspot", Host: "git.asdf.cafe"},
+			want: PackageReference{Input: "abs3nt/gspot", Host: "git.asdf.cafe", HostType: "gitea"},
+		},
+		{
+			name: "test6",
+			pkg:  PackageData{Source: "other", URL: "https://example.com/kubectl"},
+			want: PackageReference{Input: "https://example.com/kubectl"},
+		},
+		{
+			name: "test7",
+			pkg:  PackageData{Source: "gitlab", Groups: []string{"gitlab-org", "cli", "tools"}, Repo: "glab.ctl", Tag: "v1.36.0", Asset: "glab_1.36.0_Linux_x86_64.tar.gz"},
+			want: PackageReference{Input: "gitlab-org/cli/tools/glab.ctl@v1.36.0#glab_1.36.0_Linux_x86_64.tar.gz", Host: "gitlab.com", HostType: "gitlab"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GetPackageReference(tt.pkg)
+			if got != tt.want {
+				t.Errorf("GetPackageReference() = %v, want %v", got, tt.want)
+			}
+
+			parsedInput, err := ParseCLIInput(got.Input, got.HostType)
+			if err != nil {
+				t.Fatalf("ParseCLIInput(%v) error = %v", got.Input, err)
+			}
+			if tt.pkg.Source == "other" {
+				if parsedInput.DownloadURL != tt.pkg.URL {
+					t.Errorf("ParseCLIInput(%v) DownloadURL = %v, want %v", got.Input, parsedInput.DownloadURL, tt.pkg.URL)
+				}
+				return
+			}
+			parsedOwner := parsedInput.Owner
+			if tt.pkg.Source == "gitlab" {
+				parsedOwner = strings.Join(parsedInput.Groups, "/")
+			}
+			if parsedOwner != GetPackageOwner(tt.pkg) || parsedInput.Repo != tt.pkg.Repo || parsedInput.Tag != tt.pkg.Tag || parsedInput.Asset != tt.pkg.Asset {
+				t.Errorf("ParseCLIInput(%v) = %+v, want the entry %+v", got.Input, parsedInput, tt.pkg)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package stew
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// The statuses of the files checked by stew verify
+const (
+	VerifyOK         = "ok"
+	VerifyModified   = "modified"
+	VerifyMissing    = "missing"
+	VerifyUnrecorded = "unrecorded"
+)
+
+// FileVerification is the result of checking one file that stew installed in the ~/.stew/bin path
+type FileVerification struct {
+	Binary   string
+	Path     string
+	Status   string
+	Expected string
+	Actual   string
+}
+
+// InstalledBinarySHA256 returns the SHA256 digest of the binary in the version directory of an installed package
+func InstalledBinarySHA256(stewPkgPath string, pkg PackageData) (string, error) {
+	versionPath := InstalledVersionPath(stewPkgPath, pkg.Binary, InstalledVersionName(pkg))
+	return SHA256File(filepath.Join(versionPath, pkg.Binary))
+}
+
+// InstalledExtraFilesSHA256 returns the SHA256 digests of the extra files in the version directory of an installed
+// package, keyed by file name
+func InstalledExtraFilesSHA256(stewPkgPath string, pkg PackageData) (map[string]string, error) {
+	if len(pkg.InstalledExtraFiles) == 0 {
+		return nil, nil
+	}
+	versionPath := InstalledVersionPath(stewPkgPath, pkg.Binary, InstalledVersionName(pkg))
+	extraFilesSHA256 := map[string]string{}
+	for _, extraFile := range pkg.InstalledExtraFiles {
+		extraFileSHA256, err := SHA256File(filepath.Join(versionPath, extraFile))
+		if err != nil {
+			return nil, err
+		}
+		extraFilesSHA256[extraFile] = extraFileSHA256
+	}
+	return extraFilesSHA256, nil
+}
+
+// VerifyBinary checks the binary of a package and its extra files in the ~/.stew/bin path. Each file is hashed and
+// compared to the digest recorded when it was installed. A shim is not the binary itself, so the version it runs is
+// hashed instead.
+func VerifyBinary(systemInfo SystemInfo, pkg PackageData) ([]FileVerification, error) {
+	binaryPath := filepath.Join(systemInfo.StewBinPath, pkg.Binary)
+	hashedPath := binaryPath
+	if IsShim(binaryPath) {
+		hashedPath = filepath.Join(InstalledVersionPath(systemInfo.StewPkgPath, pkg.Binary, InstalledVersionName(pkg)), pkg.Binary)
+	}
+
+	verification, err := verifyFile(pkg.Binary, binaryPath, hashedPath, pkg.BinarySHA256)
+	if err != nil {
+		return nil, err
+	}
+	verifications := []FileVerification{verification}
+
+	for _, extraFile := range pkg.InstalledExtraFiles {
+		extraFilePath := filepath.Join(systemInfo.StewBinPath, extraFile)
+		verification, err := verifyFile(pkg.Binary, extraFilePath, extraFilePath, pkg.ExtraFilesSHA256[extraFile])
+		if err != nil {
+			return nil, err
+		}
+		verifications = append(verifications, verification)
+	}
+	return verifications, nil
+}
+
+// verifyFile hashes the file at hashedPath and compares it to the expected digest. The result is reported for path.
+func verifyFile(binary, path, hashedPath, expectedSHA256 string) (FileVerification, error) {
+	verification := FileVerification{Binary: binary, Path: path, Status: VerifyOK, Expected: expectedSHA256}
+	actualSHA256, err := SHA256File(hashedPath)
+	switch {
+	case os.IsNotExist(err):
+		verification.Status = VerifyMissing
+	case err != nil:
+		return FileVerification{}, err
+	case expectedSHA256 == "":
+		verification.Status = VerifyUnrecorded
+		verification.Actual = actualSHA256
+	case actualSHA256 != expectedSHA256:
+		verification.Status = VerifyModified
+		verification.Actual = actualSHA256
+	}
+	return verification, nil
+}
+
+// RepairBinary reinstalls the binary of a package from the asset that is cached in the ~/.stew/assets path. The asset is
+// downloaded again from its recorded URL if it isn't cached or if the binary in it doesn't match the recorded digest.
+func RepairBinary(systemInfo SystemInfo, pkg PackageData) error {
+	assetPath := filepath.Join(systemInfo.StewAssetPath, pkg.Asset)
+	if fileInfo, err := os.Stat(assetPath); err == nil && fileInfo.Mode().IsRegular() {
+		err := reinstallFromAsset(systemInfo, pkg, assetPath)
+		if err == nil || pkg.URL == "" {
+			return err
+		}
+	}
+	if pkg.URL == "" {
+		return CannotRepairBinaryError{Binary: pkg.Binary, Reason: fmt.Sprintf("%v is not cached and no download URL was recorded", pkg.Asset)}
+	}
+
+	if err := DownloadFile(assetPath, pkg.URL, pkg.Source); err != nil {
+		return err
+	}
+	return reinstallFromAsset(systemInfo, pkg, assetPath)
+}
+
+// reinstallFromAsset extracts the binary and extra files of a package from an asset, installs them in the version
+// directory of the package, and makes sure the binary matches the recorded digest
+func reinstallFromAsset(systemInfo SystemInfo, pkg PackageData, assetPath string) error {
+	tmpExtractionPath := filepath.Join(systemInfo.StewTmpPath, "repair")
+	if err := os.RemoveAll(tmpExtractionPath); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(tmpExtractionPath, 0755); err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpExtractionPath)
+
+	if err := extractBinary(assetPath, tmpExtractionPath, pkg.Binary); err != nil {
+		return err
+	}
+	allFilePaths, err := walkDir(tmpExtractionPath)
+	if err != nil {
+		return err
+	}
+	binaryFile, _, err := findLockedBinary(allFilePaths, pkg)
+	if err != nil {
+		return err
+	}
+	if pkg.BinarySHA256 != "" {
+		actualSHA256, err := SHA256File(binaryFile)
+		if err != nil {
+			return err
+		}
+		if actualSHA256 != pkg.BinarySHA256 {
+			return ChecksumMismatchError{Asset: pkg.Binary, Expected: pkg.BinarySHA256, Actual: actualSHA256}
+		}
+	}
+	extraFiles, err := findExtraFiles(allFilePaths, tmpExtractionPath, pkg.ExtraFiles)
+	if err != nil {
+		return err
+	}
+
+	if _, err := installVersion(systemInfo, pkg.Binary, InstalledVersionName(pkg), binaryFile, extraFiles); err != nil {
+		return err
+	}
+	return RecordInstalledVersion(systemInfo.StewPkgPath, pkg)
+}
@@ -0,0 +1,222 @@
+package stew
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestVerifiedPackage(t *testing.T, systemInfo SystemInfo) PackageData {
+	pkg := PackageData{Source: "github", Owner: "junegunn", Repo: "fzf", Tag: "0.45.0", Asset: "fzf-linux-amd64", Binary: "fzf"}
+	installTestVersion(t, systemInfo, pkg)
+	if err := os.WriteFile(filepath.Join(systemInfo.StewAssetPath, pkg.Asset), []byte(pkg.Tag), 0755); err != nil {
+		t.Fatal(err)
+	}
+	binarySHA256, err := InstalledBinarySHA256(systemInfo.StewPkgPath, pkg)
+	if err != nil {
+		t.Fatalf("InstalledBinarySHA256() error = %v", err)
+	}
+	pkg.BinarySHA256 = binarySHA256
+	return pkg
+}
+
+func TestVerifyBinary(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(t *testing.T, systemInfo SystemInfo, pkg *PackageData)
+		want   string
+	}{
+		{
+			name:   "test1",
+			modify: func(t *testing.T, systemInfo SystemInfo, pkg *PackageData) {},
+			want:   VerifyOK,
+		},
+		{
+			name: "test2",
+			modify: func(t *testing.T, systemInfo SystemInfo, pkg *PackageData) {
+				binaryPath := filepath.Join(systemInfo.StewBinPath, pkg.Binary)
+				if err := os.Remove(binaryPath); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(binaryPath, []byte("tampered"), 0755); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: VerifyModified,
+		},
+		{
+			name: "test3",
+			modify: func(t *testing.T, systemInfo SystemInfo, pkg *PackageData) {
+				if err := os.Remove(filepath.Join(systemInfo.StewBinPath, pkg.Binary)); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: VerifyMissing,
+		},
+		{
+			name: "test4",
+			modify: func(t *testing.T, systemInfo SystemInfo, pkg *PackageData) {
+				pkg.BinarySHA256 = ""
+			},
+			want: VerifyUnrecorded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			systemInfo := newTestVersionsSystemInfo(t)
+			pkg := newTestVerifiedPackage(t, systemInfo)
+			tt.modify(t, systemInfo, &pkg)
+
+			got, err := VerifyBinary(systemInfo, pkg)
+			if err != nil {
+				t.Fatalf("VerifyBinary() error = %v", err)
+			}
+			if len(got) != 1 || got[0].Status != tt.want {
+				t.Errorf("VerifyBinary() = %v, want a single file with status %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyBinary_extraFiles(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(t *testing.T, systemInfo SystemInfo, pkg *PackageData)
+		want   string
+	}{
+		{
+			name:   "test1",
+			modify: func(t *testing.T, systemInfo SystemInfo, pkg *PackageData) {},
+			want:   VerifyOK,
+		},
+		{
+			name: "test2",
+			modify: func(t *testing.T, systemInfo SystemInfo, pkg *PackageData) {
+				extraFilePath := filepath.Join(systemInfo.StewBinPath, "fzf.1")
+				if err := os.Remove(extraFilePath); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(extraFilePath, []byte("tampered"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: VerifyModified,
+		},
+		{
+			name: "test3",
+			modify: func(t *testing.T, systemInfo SystemInfo, pkg *PackageData) {
+				if err := os.Remove(filepath.Join(systemInfo.StewBinPath, "fzf.1")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: VerifyMissing,
+		},
+		{
+			name: "test4",
+			modify: func(t *testing.T, systemInfo SystemInfo, pkg *PackageData) {
+				pkg.ExtraFilesSHA256 = nil
+			},
+			want: VerifyUnrecorded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			systemInfo := newTestVersionsSystemInfo(t)
+			pkg := PackageData{Source: "github", Owner: "junegunn", Repo: "fzf", Tag: "0.45.0", Asset: "fzf-linux-amd64", Binary: "fzf"}
+			binaryFile := filepath.Join(systemInfo.StewTmpPath, pkg.Binary)
+			extraFile := filepath.Join(systemInfo.StewTmpPath, "fzf.1")
+			if err := os.WriteFile(binaryFile, []byte(pkg.Tag), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(extraFile, []byte("manual"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			extraFiles, err := installVersion(systemInfo, pkg.Binary, InstalledVersionName(pkg), binaryFile, []string{extraFile})
+			if err != nil {
+				t.Fatalf("installVersion() error = %v", err)
+			}
+			pkg.InstalledExtraFiles = extraFiles
+			if pkg.BinarySHA256, err = InstalledBinarySHA256(systemInfo.StewPkgPath, pkg); err != nil {
+				t.Fatalf("InstalledBinarySHA256() error = %v", err)
+			}
+			if pkg.ExtraFilesSHA256, err = InstalledExtraFilesSHA256(systemInfo.StewPkgPath, pkg); err != nil {
+				t.Fatalf("InstalledExtraFilesSHA256() error = %v", err)
+			}
+			tt.modify(t, systemInfo, &pkg)
+
+			got, err := VerifyBinary(systemInfo, pkg)
+			if err != nil {
+				t.Fatalf("VerifyBinary() error = %v", err)
+			}
+			if len(got) != 2 || got[0].Status != VerifyOK || got[1].Status != tt.want {
+				t.Errorf("VerifyBinary() = %v, want the extra file with status %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepairBinary(t *testing.T) {
+	systemInfo := newTestVersionsSystemInfo(t)
+	pkg := newTestVerifiedPackage(t, systemInfo)
+	binaryPath := filepath.Join(systemInfo.StewBinPath, pkg.Binary)
+	versionBinaryPath := filepath.Join(InstalledVersionPath(systemInfo.StewPkgPath, pkg.Binary, pkg.Tag), pkg.Binary)
+	if err := os.WriteFile(versionBinaryPath, []byte("tampered"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RepairBinary(systemInfo, pkg); err != nil {
+		t.Fatalf("RepairBinary() error = %v", err)
+	}
+	if got := readTestBinary(t, binaryPath); got != pkg.Tag {
+		t.Errorf("The repaired binary is %v, want %v", got, pkg.Tag)
+	}
+	if _, err := ReadInstalledVersion(systemInfo.StewPkgPath, pkg.Binary, pkg.Tag); err != nil {
+		t.Errorf("ReadInstalledVersion() error = %v", err)
+	}
+}
+
+func TestRepairBinary_Fail(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(t *testing.T, systemInfo SystemInfo, pkg PackageData)
+		wantErr error
+	}{
+		{
+			name: "test1",
+			modify: func(t *testing.T, systemInfo SystemInfo, pkg PackageData) {
+				if err := os.Remove(filepath.Join(systemInfo.StewAssetPath, pkg.Asset)); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: CannotRepairBinaryError{},
+		},
+		{
+			name: "test2",
+			modify: func(t *testing.T, systemInfo SystemInfo, pkg PackageData) {
+				if err := os.WriteFile(filepath.Join(systemInfo.StewAssetPath, pkg.Asset), []byte("tampered"), 0755); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: ChecksumMismatchError{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			systemInfo := newTestVersionsSystemInfo(t)
+			pkg := newTestVerifiedPackage(t, systemInfo)
+			tt.modify(t, systemInfo, pkg)
+
+			err := RepairBinary(systemInfo, pkg)
+			switch tt.wantErr.(type) {
+			case CannotRepairBinaryError:
+				if _, ok := err.(CannotRepairBinaryError); !ok {
+					t.Errorf("RepairBinary() error = %v, want a CannotRepairBinaryError", err)
+				}
+			case ChecksumMismatchError:
+				if _, ok := err.(ChecksumMismatchError); !ok {
+					t.Errorf("RepairBinary() error = %v, want a ChecksumMismatchError", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package stew
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// installedVersionFileName is the file in each version directory that records the lockfile entry of that version
+const installedVersionFileName = "stew.package.json"
+
+// InstalledVersionName returns the name of the directory that a version of a package is installed in.
+// Packages installed from a URL have no tag, so their asset is used instead.
+func InstalledVersionName(pkg PackageData) string {
+	if pkg.Tag != "" {
+		return pkg.Tag
+	}
+	return pkg.Asset
+}
+
+// InstalledVersionPath returns the ~/.stew/pkg/<binary>/<version> path that a version of a binary is installed in
+func InstalledVersionPath(stewPkgPath, binary, version string) string {
+	return filepath.Join(stewPkgPath, binary, version)
+}
+
+// installVersion copies the binary and its extra files into their version directory and makes that version the active one
+func installVersion(systemInfo SystemInfo, binaryName, version, binaryFile string, extraFiles []string) ([]string, error) {
+	versionsPath := filepath.Join(systemInfo.StewPkgPath, binaryName)
+	// Older versions of stew kept assets in the ~/.stew/pkg path, where an asset that is the binary itself can have
+	// the same name as the binary. It is removed to make room for the versions directory.
+	if fileInfo, err := os.Lstat(versionsPath); err == nil && !fileInfo.IsDir() {
+		if err := os.Remove(versionsPath); err != nil {
+			return nil, err
+		}
+	}
+
+	versionPath := InstalledVersionPath(systemInfo.StewPkgPath, binaryName, version)
+	if err := os.RemoveAll(versionPath); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(versionPath, 0755); err != nil {
+		return nil, err
+	}
+
+	if err := copyFile(binaryFile, filepath.Join(versionPath, binaryName)); err != nil {
+		return nil, err
+	}
+	extraFileNames := []string{}
+	for _, extraFile := range extraFiles {
+		extraFileName := filepath.Base(extraFile)
+		if err := copyFile(extraFile, filepath.Join(versionPath, extraFileName)); err != nil {
+			return nil, err
+		}
+		extraFileNames = append(extraFileNames, extraFileName)
+	}
+
+	if err := activateVersion(systemInfo, versionPath, binaryName, extraFileNames); err != nil {
+		return nil, err
+	}
+	return extraFileNames, nil
+}
+
+// activateVersion links the binary and extra files of a version directory into the ~/.stew/bin path.
+// A shim for the binary is left in place, since it already picks the version to run.
+func activateVersion(systemInfo SystemInfo, versionPath, binaryName string, extraFiles []string) error {
+	fileNames := extraFiles
+	if !IsShim(filepath.Join(systemInfo.StewBinPath, binaryName)) {
+		fileNames = append([]string{binaryName}, extraFiles...)
+	}
+	for _, fileName := range fileNames {
+		if err := placeFile(systemInfo, filepath.Join(versionPath, fileName), filepath.Join(systemInfo.StewBinPath, fileName)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// placeFile puts a file of a version directory in the ~/.stew/bin path. It is linked, unless the SystemInfo copies
+// binaries, in which case it is copied next to the destination and renamed over it.
+func placeFile(systemInfo SystemInfo, srcFile, destFile string) error {
+	if !systemInfo.CopyBinaries {
+		return linkFile(srcFile, destFile)
+	}
+	tmpCopyPath := destFile + ".stew-copy"
+	if err := copyFile(srcFile, tmpCopyPath); err != nil {
+		return err
+	}
+	if fileInfo, err := os.Lstat(destFile); err == nil && fileInfo.IsDir() {
+		if err := os.RemoveAll(destFile); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmpCopyPath, destFile)
+}
+
+// linkFile creates a symlink to the source file. The symlink is created next to the destination and renamed over it,
+// so the destination is replaced atomically. The file is copied instead if symlinks can't be created, which is the case
+// on Windows without developer mode.
+func linkFile(srcFile, destFile string) error {
+	tmpLinkPath := destFile + ".stew-link"
+	if err := os.RemoveAll(tmpLinkPath); err != nil {
+		return err
+	}
+	if err := os.Symlink(srcFile, tmpLinkPath); err != nil {
+		if err := os.RemoveAll(destFile); err != nil {
+			return err
+		}
+		return copyFile(srcFile, destFile)
+	}
+	if fileInfo, err := os.Lstat(destFile); err == nil && fileInfo.IsDir() {
+		if err := os.RemoveAll(destFile); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmpLinkPath, destFile)
+}
+
+// RecordInstalledVersion saves the lockfile entry of an installed version so that it can be switched back to later
+func RecordInstalledVersion(stewPkgPath string, pkg PackageData) error {
+	versionPath := InstalledVersionPath(stewPkgPath, pkg.Binary, InstalledVersionName(pkg))
+	versionInstalled, err := PathExists(versionPath)
+	if err != nil {
+		return err
+	}
+	if !versionInstalled {
+		return nil
+	}
+	pkgBytes, err := json.MarshalIndent(pkg, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(versionPath, installedVersionFileName), append(pkgBytes, '\n'), 0644)
+}
+
+// ReadInstalledVersion reads the lockfile entry of an installed version of a binary
+func ReadInstalledVersion(stewPkgPath, binary, version string) (PackageData, error) {
+	pkgBytes, err := os.ReadFile(filepath.Join(InstalledVersionPath(stewPkgPath, binary, version), installedVersionFileName))
+	if os.IsNotExist(err) {
+		return PackageData{}, VersionNotInstalledError{Binary: binary, Version: version}
+	}
+	if err != nil {
+		return PackageData{}, err
+	}
+	var pkg PackageData
+	if err := json.Unmarshal(pkgBytes, &pkg); err != nil {
+		return PackageData{}, err
+	}
+	return pkg, nil
+}
+
+// ListInstalledVersions returns the installed versions of a binary, newest first
+func ListInstalledVersions(stewPkgPath, binary string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(stewPkgPath, binary))
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	versions := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		recorded, err := PathExists(filepath.Join(stewPkgPath, binary, entry.Name(), installedVersionFileName))
+		if err != nil {
+			return nil, err
+		}
+		if recorded {
+			versions = append(versions, entry.Name())
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return CompareTags(versions[i], versions[j]) > 0
+	})
+	return versions, nil
+}
+
+// UseVersion makes an installed version of a binary the active one and replaces its entry in the lockfile
+func UseVersion(systemInfo SystemInfo, lockFile *LockFile, binary, version string) error {
+	indexInLockFile, binaryFoundInLockFile := FindBinaryInLockFile(*lockFile, binary)
+	if !binaryFoundInLockFile {
+		return BinaryNotInstalledError{Binary: binary}
+	}
+	versionPkg, err := ReadInstalledVersion(systemInfo.StewPkgPath, binary, version)
+	if err != nil {
+		return err
+	}
+
+	if err := DeleteExtraFiles(systemInfo.StewBinPath, lockFile.Packages[indexInLockFile].InstalledExtraFiles); err != nil {
+		return err
+	}
+	versionPath := InstalledVersionPath(systemInfo.StewPkgPath, binary, version)
+	if err := activateVersion(systemInfo, versionPath, binary, versionPkg.InstalledExtraFiles); err != nil {
+		return err
+	}
+	lockFile.Packages[indexInLockFile] = versionPkg
+	return nil
+}
+
+// DeleteInstalledVersions deletes every installed version of a binary along with the assets they were installed from
+func DeleteInstalledVersions(systemInfo SystemInfo, binary string) error {
+	versionsPath := filepath.Join(systemInfo.StewPkgPath, binary)
+	versions, err := ListInstalledVersions(systemInfo.StewPkgPath, binary)
+	if err != nil {
+		return err
+	}
+	for _, version := range versions {
+		versionPkg, err := ReadInstalledVersion(systemInfo.StewPkgPath, binary, version)
+		if err != nil {
+			return err
+		}
+		if err := DeleteCachedAsset(systemInfo.StewAssetPath, versionPkg.Asset); err != nil {
+			return err
+		}
+	}
+	if fileInfo, err := os.Lstat(versionsPath); err == nil && fileInfo.IsDir() {
+		return os.RemoveAll(versionsPath)
+	}
+	return nil
+}
+
+// RenameInstalledVersions renames the binary in every installed version of a package and links the active
+// version into the ~/.stew/bin path under the new name
+func RenameInstalledVersions(systemInfo SystemInfo, pkg PackageData, renamedBinaryName string) error {
+	versions, err := ListInstalledVersions(systemInfo.StewPkgPath, pkg.Binary)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		return os.Rename(filepath.Join(systemInfo.StewBinPath, pkg.Binary), filepath.Join(systemInfo.StewBinPath, renamedBinaryName))
+	}
+
+	if err := os.Rename(filepath.Join(systemInfo.StewPkgPath, pkg.Binary), filepath.Join(systemInfo.StewPkgPath, renamedBinaryName)); err != nil {
+		return err
+	}
+	for _, version := range versions {
+		versionPkg, err := ReadInstalledVersion(systemInfo.StewPkgPath, renamedBinaryName, version)
+		if err != nil {
+			return err
+		}
+		versionPath := InstalledVersionPath(systemInfo.StewPkgPath, renamedBinaryName, version)
+		if err := os.Rename(filepath.Join(versionPath, pkg.Binary), filepath.Join(versionPath, renamedBinaryName)); err != nil {
+			return err
+		}
+		versionPkg.Binary = renamedBinaryName
+		if err := RecordInstalledVersion(systemInfo.StewPkgPath, versionPkg); err != nil {
+			return err
+		}
+	}
+
+	shimmed := IsShim(filepath.Join(systemInfo.StewBinPath, pkg.Binary))
+	if err := os.RemoveAll(filepath.Join(systemInfo.StewBinPath, pkg.Binary)); err != nil {
+		return err
+	}
+	if shimmed {
+		return CreateShim(systemInfo.StewBinPath, renamedBinaryName)
+	}
+	versionPath := InstalledVersionPath(systemInfo.StewPkgPath, renamedBinaryName, InstalledVersionName(pkg))
+	return placeFile(systemInfo, filepath.Join(versionPath, renamedBinaryName), filepath.Join(systemInfo.StewBinPath, renamedBinaryName))
+}
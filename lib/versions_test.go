@@ -0,0 +1,185 @@
+package stew
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func newTestVersionsSystemInfo(t *testing.T) SystemInfo {
+	tempDir := t.TempDir()
+	systemInfo := SystemInfo{
+		StewPath:         tempDir,
+		StewBinPath:      filepath.Join(tempDir, "bin"),
+		StewPkgPath:      filepath.Join(tempDir, "pkg"),
+		StewAssetPath:    filepath.Join(tempDir, "assets"),
+		StewLockFilePath: filepath.Join(tempDir, "Stewfile.lock.json"),
+		StewTmpPath:      filepath.Join(tempDir, "tmp"),
+	}
+	for _, path := range []string{systemInfo.StewBinPath, systemInfo.StewPkgPath, systemInfo.StewAssetPath, systemInfo.StewTmpPath} {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return systemInfo
+}
+
+func installTestVersion(t *testing.T, systemInfo SystemInfo, pkg PackageData) {
+	binaryFile := filepath.Join(systemInfo.StewTmpPath, pkg.Binary)
+	if err := os.WriteFile(binaryFile, []byte(pkg.Tag), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := installVersion(systemInfo, pkg.Binary, InstalledVersionName(pkg), binaryFile, nil); err != nil {
+		t.Fatalf("installVersion() error = %v", err)
+	}
+	if err := RecordInstalledVersion(systemInfo.StewPkgPath, pkg); err != nil {
+		t.Fatalf("RecordInstalledVersion() error = %v", err)
+	}
+}
+
+func readTestBinary(t *testing.T, path string) string {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(contents)
+}
+
+func TestInstalledVersionName(t *testing.T) {
+	tests := []struct {
+		name string
+		pkg  PackageData
+		want string
+	}{
+		{
+			name: "test1",
+			pkg:  PackageData{Tag: "v1.5.7", Asset: "terraform_1.5.7_linux_amd64.zip"},
+			want: "v1.5.7",
+		},
+		{
+			name: "test2",
+			pkg:  PackageData{Source: "other", Asset: "kubectl"},
+			want: "kubectl",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InstalledVersionName(tt.pkg); got != tt.want {
+				t.Errorf("InstalledVersionName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUseVersion(t *testing.T) {
+	systemInfo := newTestVersionsSystemInfo(t)
+	oldPkg := PackageData{Source: "github", Owner: "hashicorp", Repo: "terraform", Tag: "v1.5.7", Asset: "terraform_1.5.7.zip", Binary: "terraform"}
+	newPkg := PackageData{Source: "github", Owner: "hashicorp", Repo: "terraform", Tag: "v1.6.0", Asset: "terraform_1.6.0.zip", Binary: "terraform"}
+	installTestVersion(t, systemInfo, oldPkg)
+	installTestVersion(t, systemInfo, newPkg)
+	lockFile := LockFile{Packages: []PackageData{newPkg}}
+	binaryPath := filepath.Join(systemInfo.StewBinPath, "terraform")
+
+	versions, err := ListInstalledVersions(systemInfo.StewPkgPath, "terraform")
+	if err != nil {
+		t.Fatalf("ListInstalledVersions() error = %v", err)
+	}
+	if want := []string{"v1.6.0", "v1.5.7"}; !reflect.DeepEqual(versions, want) {
+		t.Errorf("ListInstalledVersions() = %v, want %v", versions, want)
+	}
+	if got := readTestBinary(t, binaryPath); got != "v1.6.0" {
+		t.Errorf("The active binary is %v, want v1.6.0", got)
+	}
+
+	if err := UseVersion(systemInfo, &lockFile, "terraform", "v1.5.7"); err != nil {
+		t.Fatalf("UseVersion() error = %v", err)
+	}
+	if got := readTestBinary(t, binaryPath); got != "v1.5.7" {
+		t.Errorf("The active binary is %v, want v1.5.7", got)
+	}
+	if !reflect.DeepEqual(lockFile.Packages[0], oldPkg) {
+		t.Errorf("UseVersion() lockfile entry = %v, want %v", lockFile.Packages[0], oldPkg)
+	}
+
+	if err := UseVersion(systemInfo, &lockFile, "terraform", "v1.4.0"); err == nil {
+		t.Errorf("UseVersion() expected an error for a version that is not installed")
+	}
+	if err := UseVersion(systemInfo, &lockFile, "packer", "v1.5.7"); err == nil {
+		t.Errorf("UseVersion() expected an error for a binary that is not installed")
+	}
+}
+
+func TestUseVersion_copyBinaries(t *testing.T) {
+	systemInfo := newTestVersionsSystemInfo(t)
+	systemInfo.CopyBinaries = true
+	oldPkg := PackageData{Source: "github", Owner: "hashicorp", Repo: "terraform", Tag: "v1.5.7", Asset: "terraform_1.5.7.zip", Binary: "terraform"}
+	newPkg := PackageData{Source: "github", Owner: "hashicorp", Repo: "terraform", Tag: "v1.6.0", Asset: "terraform_1.6.0.zip", Binary: "terraform"}
+	installTestVersion(t, systemInfo, oldPkg)
+	installTestVersion(t, systemInfo, newPkg)
+	lockFile := LockFile{Packages: []PackageData{newPkg}}
+	binaryPath := filepath.Join(systemInfo.StewBinPath, "terraform")
+
+	if err := UseVersion(systemInfo, &lockFile, "terraform", "v1.5.7"); err != nil {
+		t.Fatalf("UseVersion() error = %v", err)
+	}
+	fileInfo, err := os.Lstat(binaryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fileInfo.Mode().IsRegular() {
+		t.Errorf("The active binary is not a copy: %v", fileInfo.Mode())
+	}
+	if got := readTestBinary(t, binaryPath); got != "v1.5.7" {
+		t.Errorf("The active binary is %v, want v1.5.7", got)
+	}
+	// The copy doesn't depend on the version directory
+	if err := os.RemoveAll(filepath.Join(systemInfo.StewPkgPath, "terraform")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestBinary(t, binaryPath); got != "v1.5.7" {
+		t.Errorf("The active binary is %v after deleting its version directory, want v1.5.7", got)
+	}
+}
+
+func TestRenameInstalledVersions(t *testing.T) {
+	systemInfo := newTestVersionsSystemInfo(t)
+	pkg := PackageData{Source: "github", Owner: "junegunn", Repo: "fzf", Tag: "0.45.0", Asset: "fzf.tar.gz", Binary: "fzf"}
+	installTestVersion(t, systemInfo, pkg)
+
+	if err := RenameInstalledVersions(systemInfo, pkg, "fuzzy"); err != nil {
+		t.Fatalf("RenameInstalledVersions() error = %v", err)
+	}
+	if got := readTestBinary(t, filepath.Join(systemInfo.StewBinPath, "fuzzy")); got != "0.45.0" {
+		t.Errorf("The renamed binary is %v, want 0.45.0", got)
+	}
+	if oldBinaryExists, _ := PathExists(filepath.Join(systemInfo.StewBinPath, "fzf")); oldBinaryExists {
+		t.Errorf("The binary was not removed under its old name")
+	}
+	versionPkg, err := ReadInstalledVersion(systemInfo.StewPkgPath, "fuzzy", "0.45.0")
+	if err != nil {
+		t.Fatalf("ReadInstalledVersion() error = %v", err)
+	}
+	if versionPkg.Binary != "fuzzy" {
+		t.Errorf("ReadInstalledVersion() binary = %v, want fuzzy", versionPkg.Binary)
+	}
+}
+
+func TestDeleteInstalledVersions(t *testing.T) {
+	systemInfo := newTestVersionsSystemInfo(t)
+	pkg := PackageData{Source: "github", Owner: "junegunn", Repo: "fzf", Tag: "0.45.0", Asset: "fzf.tar.gz", Binary: "fzf"}
+	assetPath := filepath.Join(systemInfo.StewAssetPath, pkg.Asset)
+	if err := os.WriteFile(assetPath, []byte("asset"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	installTestVersion(t, systemInfo, pkg)
+
+	if err := DeleteInstalledVersions(systemInfo, "fzf"); err != nil {
+		t.Fatalf("DeleteInstalledVersions() error = %v", err)
+	}
+	for _, path := range []string{assetPath, filepath.Join(systemInfo.StewPkgPath, "fzf")} {
+		if exists, _ := PathExists(path); exists {
+			t.Errorf("%v was not deleted", path)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package stew
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// installOperations are the operations of the history journal that put a version of a binary in place
+var installOperations = map[string]bool{"install": true, "upgrade": true, "adopt": true, "rollback": true, "use": true}
+
+// FindOwningPackage finds the lockfile entry that a file belongs to. The file can be a binary or extra file in the
+// ~/.stew/bin path, or a file in one of the version directories in the ~/.stew/pkg path.
+func FindOwningPackage(systemInfo SystemInfo, lockFile LockFile, filePath string) (PackageData, bool) {
+	if samePath(filepath.Dir(filePath), systemInfo.StewBinPath) {
+		fileName := filepath.Base(filePath)
+		for _, pkg := range lockFile.Packages {
+			if pkg.Binary == fileName {
+				return pkg, true
+			}
+			if _, found := Contains(pkg.InstalledExtraFiles, fileName); found {
+				return pkg, true
+			}
+		}
+	}
+
+	resolvedPath, err := filepath.EvalSymlinks(filePath)
+	if err != nil {
+		return PackageData{}, false
+	}
+	stewPkgPath, err := filepath.EvalSymlinks(systemInfo.StewPkgPath)
+	if err != nil {
+		return PackageData{}, false
+	}
+	relativePath, err := filepath.Rel(stewPkgPath, resolvedPath)
+	if err != nil || relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) {
+		return PackageData{}, false
+	}
+	binary, _, found := strings.Cut(filepath.ToSlash(relativePath), "/")
+	if !found {
+		return PackageData{}, false
+	}
+	indexInLockFile, binaryFoundInLockFile := FindBinaryInLockFile(lockFile, binary)
+	if !binaryFoundInLockFile {
+		return PackageData{}, false
+	}
+	return lockFile.Packages[indexInLockFile], true
+}
+
+// InstallTime returns when the active version of a binary was put in place. It is read from the history journal,
+// and falls back to when the version was recorded in its version directory.
+func InstallTime(stewPkgPath string, entries []HistoryEntry, pkg PackageData) (time.Time, bool) {
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if entry.Binary == pkg.Binary && entry.Result == HistoryResultSuccess && installOperations[entry.Operation] {
+			return entry.Time, true
+		}
+	}
+	versionPath := InstalledVersionPath(stewPkgPath, pkg.Binary, InstalledVersionName(pkg))
+	fileInfo, err := os.Stat(filepath.Join(versionPath, installedVersionFileName))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return fileInfo.ModTime(), true
+}
+
+// PackageHost returns the host that a package was installed from [Ex: github.com]
+func PackageHost(pkg PackageData) string {
+	switch pkg.Source {
+	case "github":
+		return "github.com"
+	case "gitlab", "gitea":
+		return GetPackageReference(pkg).Host
+	}
+	if parsedURL, err := url.Parse(pkg.URL); err == nil {
+		return parsedURL.Host
+	}
+	return ""
+}
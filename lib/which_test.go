@@ -0,0 +1,113 @@
+package stew
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFindOwningPackage(t *testing.T) {
+	systemInfo := newTestVersionsSystemInfo(t)
+	pkg := PackageData{Source: "github", Owner: "junegunn", Repo: "fzf", Tag: "0.45.0", Asset: "fzf.tar.gz", Binary: "fzf", InstalledExtraFiles: []string{"fzf-tmux"}}
+	installTestVersion(t, systemInfo, pkg)
+	lockFile := LockFile{Packages: []PackageData{pkg}}
+	otherBinary := filepath.Join(t.TempDir(), "fzf")
+	if err := os.WriteFile(otherBinary, []byte("binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		filePath  string
+		wantFound bool
+	}{
+		{
+			name:      "test1",
+			filePath:  filepath.Join(systemInfo.StewBinPath, "fzf"),
+			wantFound: true,
+		},
+		{
+			name:      "test2",
+			filePath:  filepath.Join(InstalledVersionPath(systemInfo.StewPkgPath, "fzf", "0.45.0"), "fzf"),
+			wantFound: true,
+		},
+		{
+			name:      "test3",
+			filePath:  filepath.Join(systemInfo.StewBinPath, "fzf-tmux"),
+			wantFound: true,
+		},
+		{
+			name:      "test4",
+			filePath:  otherBinary,
+			wantFound: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := FindOwningPackage(systemInfo, lockFile, tt.filePath)
+			if found != tt.wantFound {
+				t.Fatalf("FindOwningPackage() found = %v, want %v", found, tt.wantFound)
+			}
+			if found && got.Binary != "fzf" {
+				t.Errorf("FindOwningPackage() = %v, want the fzf package", got)
+			}
+		})
+	}
+}
+
+func TestInstallTime(t *testing.T) {
+	systemInfo := newTestVersionsSystemInfo(t)
+	pkg := PackageData{Source: "github", Tag: "0.45.0", Asset: "fzf.tar.gz", Binary: "fzf"}
+	installTestVersion(t, systemInfo, pkg)
+	installedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	entries := []HistoryEntry{
+		{Time: installedAt, Operation: "install", Binary: "fzf", Result: HistoryResultSuccess},
+		{Time: installedAt.Add(time.Hour), Operation: "upgrade", Binary: "fzf", Result: "Error: failed"},
+		{Time: installedAt.Add(2 * time.Hour), Operation: "repair", Binary: "fzf", Result: HistoryResultSuccess},
+	}
+
+	got, found := InstallTime(systemInfo.StewPkgPath, entries, pkg)
+	if !found || !got.Equal(installedAt) {
+		t.Errorf("InstallTime() = %v, %v, want %v, true", got, found, installedAt)
+	}
+	if _, found := InstallTime(systemInfo.StewPkgPath, []HistoryEntry{}, pkg); !found {
+		t.Errorf("InstallTime() didn't fall back to the version directory")
+	}
+}
+
+func TestPackageHost(t *testing.T) {
+	tests := []struct {
+		name string
+		pkg  PackageData
+		want string
+	}{
+		{
+			name: "test1",
+			pkg:  PackageData{Source: "github", Owner: "junegunn", Repo: "fzf"},
+			want: "github.com",
+		},
+		{
+			name: "test2",
+			pkg:  PackageData{Source: "gitlab", Groups: []string{"gitlab-org"}, Repo: "cli"},
+			want: "gitlab.com",
+		},
+		{
+			name: "test3",
+			pkg:  PackageData{Source: "gitea", Owner: "owner", Repo: "repo", Host: "gitea.example.com"},
+			want: "gitea.example.com",
+		},
+		{
+			name: "test4",
+			pkg:  PackageData{Source: "other", URL: "https://dl.k8s.io/release/v1.29.0/bin/linux/amd64/kubectl"},
+			want: "dl.k8s.io",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PackageHost(tt.pkg); got != tt.want {
+				t.Errorf("PackageHost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
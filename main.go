@@ -10,14 +10,21 @@ import (
 	"github.com/urfave/cli/v3"
 
 	"github.com/marwanhawari/stew/cmd"
+	"github.com/marwanhawari/stew/constants"
 	stew "github.com/marwanhawari/stew/lib"
 )
 
 func main() {
+	// Shims are links to the stew executable, so the name it was invoked as is the binary to run
+	if shimName, isShim := cmd.ShimInvocation(os.Args[0]); isShim {
+		cmd.RunShim(shimName, os.Args[1:])
+		return
+	}
+
 	app := &cli.Command{
 		Name:                  "stew",
 		EnableShellCompletion: true,
-		Version:               "v0.3.0",
+		Version:               constants.StewVersion,
 		Commands: []*cli.Command{
 			{
 				Name:    "install",
@@ -32,11 +39,47 @@ func main() {
 						Name:  "host-type",
 						Usage: "specify the type of git host [Ex: gitea]",
 					},
+					&cli.StringFlag{
+						Name:  "os",
+						Usage: "resolve assets for a different OS [Ex: linux]",
+					},
+					&cli.StringFlag{
+						Name:  "arch",
+						Usage: "resolve assets for a different arch [Ex: arm64]",
+					},
+					&cli.StringFlag{
+						Name:  "bin-path",
+						Usage: "install the binaries and their lockfile into this directory instead of the stewBinPath",
+					},
+					&cli.BoolFlag{
+						Name:  "lock-only",
+						Usage: "only resolve the assets and write the lockfile, without downloading or installing anything",
+					},
+					&cli.BoolFlag{
+						Name:  "frozen",
+						Usage: "install exactly the assets recorded in the lockfile and fail instead of prompting if anything differs",
+					},
+					&cli.StringSliceFlag{
+						Name:  "group",
+						Usage: "only install the Stewfile entries of these groups, along with the entries without a group [Ex: k8s,core]",
+					},
+					&cli.BoolFlag{
+						Name:  "no-keep-asset",
+						Usage: "delete the downloaded asset after the binary is installed",
+					},
 				},
 				Action: func(ctx context.Context, c *cli.Command) error {
-					host := c.String("host")
-					hostType := c.String("host-type")
-					cmd.Install(host, hostType, c.Args().Slice())
+					cmd.Install(c.Args().Slice(), cmd.InstallOptions{
+						Host:        c.String("host"),
+						HostType:    c.String("host-type"),
+						OS:          c.String("os"),
+						Arch:        c.String("arch"),
+						BinPath:     c.String("bin-path"),
+						LockOnly:    c.Bool("lock-only"),
+						Frozen:      c.Bool("frozen"),
+						Groups:      c.StringSlice("group"),
+						NoKeepAsset: c.Bool("no-keep-asset"),
+					})
 					return nil
 				},
 			},
@@ -91,10 +134,48 @@ func main() {
 						Name:  "all",
 						Usage: "Upgrade all binaries",
 					},
+					&cli.BoolFlag{
+						Name:  "no-keep-asset",
+						Usage: "delete the downloaded asset after the binary is upgraded",
+					},
+					&cli.BoolFlag{
+						Name:  "changelog",
+						Usage: "show the release notes since the installed version before upgrading",
+					},
 				},
 				ShellComplete: listInstalledBinaries,
 				Action: func(ctx context.Context, c *cli.Command) error {
-					cmd.Upgrade(c.Bool("all"), c.Args().First())
+					cmd.Upgrade(c.Bool("all"), c.Bool("no-keep-asset"), c.Bool("changelog"), c.Args().First())
+					return nil
+				},
+			},
+			{
+				Name:  "changelog",
+				Usage: "Show the release notes between the installed version of a binary and the one stew upgrade would install. [Ex: stew changelog fzf]",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "all",
+						Usage: "Show the release notes for all binaries",
+					},
+				},
+				ShellComplete: listInstalledBinaries,
+				Action: func(ctx context.Context, c *cli.Command) error {
+					cmd.Changelog(c.Bool("all"), c.Args().First())
+					return nil
+				},
+			},
+			{
+				Name:  "rollback",
+				Usage: "Restore the version of a binary from before its last upgrade. [Ex: stew rollback fzf]",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "all",
+						Usage: "Roll back every binary from the last run of stew upgrade --all",
+					},
+				},
+				ShellComplete: listInstalledBinaries,
+				Action: func(ctx context.Context, c *cli.Command) error {
+					cmd.Rollback(c.Bool("all"), c.Args().First())
 					return nil
 				},
 			},
@@ -124,6 +205,100 @@ func main() {
 					return nil
 				},
 			},
+			{
+				Name:  "use",
+				Usage: "Switch a binary to another installed version. [Ex: stew use terraform@v1.5.7]",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "local",
+						Usage: "pin the version in the .stew-version file of the current directory instead",
+					},
+				},
+				ShellComplete: listInstalledBinaries,
+				Action: func(ctx context.Context, c *cli.Command) error {
+					cmd.Use(c.Bool("local"), c.Args().First())
+					return nil
+				},
+			},
+			{
+				Name:  "shim",
+				Usage: "Replace a binary with a shim that runs the version pinned by the nearest .stew-version file or Stewfile. [Ex: stew shim kubectl]",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "remove",
+						Usage: "link the active version back instead of the shim",
+					},
+				},
+				ShellComplete: listInstalledBinaries,
+				Action: func(ctx context.Context, c *cli.Command) error {
+					cmd.Shim(c.Bool("remove"), c.Args().First())
+					return nil
+				},
+			},
+			{
+				Name:  "adopt",
+				Usage: "Manage binaries that were installed without stew. Without input, every unmanaged binary in the stewBinPath is offered. [Ex: stew adopt --from BurntSushi/ripgrep rg]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "from",
+						Usage: "the GitHub repo that the binary came from, optionally with a tag [Ex: BurntSushi/ripgrep@14.1.0]",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					cmd.Adopt(c.String("from"), c.Args().Slice())
+					return nil
+				},
+			},
+			{
+				Name:          "versions",
+				Usage:         "List the installed versions of a binary. [Ex: stew versions terraform]",
+				ShellComplete: listInstalledBinaries,
+				Action: func(ctx context.Context, c *cli.Command) error {
+					cmd.Versions(c.Args().First())
+					return nil
+				},
+			},
+			{
+				Name:          "which",
+				Usage:         "Show which package a binary or path was installed from. [Ex: stew which rg]",
+				ShellComplete: listInstalledBinaries,
+				Action: func(ctx context.Context, c *cli.Command) error {
+					cmd.Which(c.Args().First())
+					return nil
+				},
+			},
+			{
+				Name:          "info",
+				Usage:         "Show the details and release notes of an installed binary or a repo. [Ex: stew info rg]",
+				ShellComplete: listInstalledBinaries,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "print the details as JSON",
+					},
+					&cli.StringFlag{
+						Name:  "host",
+						Usage: "specify the custom host",
+					},
+					&cli.StringFlag{
+						Name:  "host-type",
+						Usage: "specify the type of git host [Ex: gitea]",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					cmd.Info(c.String("host"), c.String("host-type"), c.Bool("json"), c.Args().First())
+					return nil
+				},
+			},
+			{
+				Name:          "history",
+				Usage:         "Show the installs, upgrades, uninstalls and renames that stew has done. [Ex: stew history rg]",
+				ShellComplete: listInstalledBinaries,
+				Action: func(ctx context.Context, c *cli.Command) error {
+					cmd.History(c.Args().First())
+					return nil
+				},
+			},
 			{
 				Name:    "list",
 				Usage:   "List installed binaries [Ex: stew list]",
@@ -139,6 +314,152 @@ func main() {
 					return nil
 				},
 			},
+			{
+				Name:  "sync",
+				Usage: "Install, upgrade, or downgrade binaries so that they match a Stewfile. [Ex: stew sync Stewfile]",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "prune",
+						Usage: "uninstall binaries that are not in the Stewfile",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "only print the changes that would be made",
+					},
+					&cli.StringSliceFlag{
+						Name:  "group",
+						Usage: "only sync the Stewfile entries of these groups, defaults to the groups that were last installed [Ex: k8s,core]",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					cmd.Sync(c.Args().First(), c.StringSlice("group"), c.Bool("prune"), c.Bool("dry-run"))
+					return nil
+				},
+			},
+			{
+				Name:  "validate",
+				Usage: "Check a Stewfile for mistakes without installing anything. [Ex: stew validate Stewfile]",
+				Action: func(ctx context.Context, c *cli.Command) error {
+					cmd.Validate(c.Args().First())
+					return nil
+				},
+			},
+			{
+				Name:  "convert",
+				Usage: "Print a Stewfile in the structured TOML format. [Ex: stew convert Stewfile > Stewfile.toml]",
+				Action: func(ctx context.Context, c *cli.Command) error {
+					cmd.Convert(c.Args().First())
+					return nil
+				},
+			},
+			{
+				Name:  "freeze",
+				Usage: "Print the installed binaries as a Stewfile. [Ex: stew freeze > Stewfile]",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "no-tags",
+						Usage: "leave out the tags so that the latest releases are installed",
+					},
+					&cli.BoolFlag{
+						Name:  "no-assets",
+						Usage: "leave out the assets so that they are detected for each platform",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					cmd.Freeze(c.Bool("no-tags"), c.Bool("no-assets"))
+					return nil
+				},
+			},
+			{
+				Name:  "lock",
+				Usage: "Resolve a Stewfile or the installed binaries into a lockfile without installing anything. [Ex: stew lock Stewfile --platform linux/amd64,darwin/arm64]",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "platform",
+						Usage: "the OS/arch platforms to lock [Ex: linux/arm64]",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					cmd.Lock(c.Args().First(), c.StringSlice("platform"))
+					return nil
+				},
+				Commands: []*cli.Command{
+					{
+						Name:  "migrate",
+						Usage: "Rewrite a lockfile with the current schema version. Defaults to the lockfile of the installed binaries. [Ex: stew lock migrate Stewfile.lock.json]",
+						Action: func(ctx context.Context, c *cli.Command) error {
+							cmd.LockMigrate(c.Args().First())
+							return nil
+						},
+					},
+				},
+			},
+			{
+				Name:  "env",
+				Usage: "Print the PATH addition for the project-local stew environment in the current directory or its parents. [Ex: eval \"$(stew env)\"]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "shell",
+						Usage: "the shell to print the PATH addition for [sh, fish, powershell]",
+						Value: "sh",
+					},
+					&cli.BoolFlag{
+						Name:  "init",
+						Usage: "create a .stew environment in the current directory first",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					cmd.Env(c.String("shell"), c.Bool("init"))
+					return nil
+				},
+			},
+			{
+				Name:  "gc",
+				Usage: "Delete the downloaded assets and installed versions that are no longer needed. [Ex: stew gc --dry-run]",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "only print what would be deleted",
+					},
+					&cli.BoolFlag{
+						Name:  "prune-versions",
+						Usage: "also delete the installed versions that aren't active or needed for a rollback, even if a .stew-version file pins them",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					cmd.GC(c.Bool("dry-run"), c.Bool("prune-versions"))
+					return nil
+				},
+			},
+			{
+				Name:          "verify",
+				Usage:         "Check that the installed binaries match the SHA256 digests recorded when they were installed. [Ex: stew verify --repair]",
+				ShellComplete: listInstalledBinaries,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "repair",
+						Usage: "reinstall modified or missing binaries from the cached asset or the recorded URL",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					cmd.Verify(c.Bool("repair"), c.Args().First())
+					return nil
+				},
+			},
+			{
+				Name:  "doctor",
+				Usage: "Check the stew installation for problems and suggest fixes. [Ex: stew doctor --fix]",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "fix",
+						Usage: "fix the problems that can be fixed automatically",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					cmd.Doctor(c.Bool("fix"))
+					return nil
+				},
+			},
 			{
 				Name:  "config",
 				Usage: "Configure the stew file paths using an interactive UI. [Ex: stew config]",